@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -11,9 +12,15 @@ import (
 
 	"order-service/internal/cache"
 	"order-service/internal/config"
+	"order-service/internal/correlation"
 	"order-service/internal/handlers"
+	"order-service/internal/health"
 	"order-service/internal/kafka"
+	"order-service/internal/metrics"
+	"order-service/internal/models"
+	"order-service/internal/outbox"
 	"order-service/internal/repository"
+	"order-service/internal/tracing"
 
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
@@ -23,50 +30,166 @@ func main() {
 	logger := logrus.New()
 	logger.SetLevel(logrus.InfoLevel)
 	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.AddHook(correlation.Hook{})
 
-	cfg := config.LoadConfig()
-	logger.Infof("Configuration loaded: DB=%s:%s, Kafka=%v, Server=:%s",
-		cfg.Database.Host, cfg.Database.Port, cfg.Kafka.Brokers, cfg.Server.Port)
+	loader, args, err := config.NewLoader(os.Args[1:])
+	if err != nil {
+		logger.Fatalf("Invalid command line: %v", err)
+	}
+
+	cfg, err := loader.Load()
+	if err != nil {
+		logger.Fatalf("Invalid configuration: %v", err)
+	}
+	level, _ := logrus.ParseLevel(cfg.Log.Level)
+	logger.SetLevel(level)
+	logger.WithFields(logrus.Fields{
+		"config_file": loader.Path(),
+		"config":      cfg.Map(),
+	}).Info("Configuration loaded")
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Database.Host, cfg.Database.Port, cfg.Database.User,
-		cfg.Database.Password, cfg.Database.DBName, cfg.Database.SSLMode)
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		logger.Fatalf("Failed to set up tracing: %v", err)
+	}
+	defer func() {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			logger.Errorf("Failed to flush traces: %v", err)
+		}
+	}()
 
-	repo, err := repository.NewPostgresRepository(dsn, logger)
+	repo, err := repository.NewPostgresRepository(ctx, repository.Options{
+		DSN:               cfg.Database.DSN(),
+		MaxOpenConns:      cfg.Database.Pool.MaxOpenConns,
+		MaxIdleConns:      cfg.Database.Pool.MaxIdleConns,
+		ConnMaxLifetime:   cfg.Database.Pool.ConnMaxLifetime,
+		ConnMaxIdleTime:   cfg.Database.Pool.ConnMaxIdleTime,
+		ConnectAttempts:   cfg.Database.Connect.MaxAttempts,
+		ConnectBackoff:    cfg.Database.Connect.InitialBackoff,
+		ConnectMaxBackoff: cfg.Database.Connect.MaxBackoff,
+		Timeouts: repository.Timeouts{
+			Save:   cfg.Database.Timeouts.Save,
+			Get:    cfg.Database.Timeouts.Get,
+			Load:   cfg.Database.Timeouts.Load,
+			Search: cfg.Database.Timeouts.Search,
+		},
+	}, logger)
 	if err != nil {
 		logger.Fatalf("Failed to connect to database: %v", err)
 	}
 	defer repo.Close()
 	logger.Info("Database connection established")
 
-	memCache := cache.NewMemoryCache(logger)
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrateCommand(args[1:], repo, logger); err != nil {
+			logger.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	if cfg.Database.AutoMigrate {
+		if err := applyMigrations(ctx, repo, logger); err != nil {
+			logger.Fatalf("Failed to apply database migrations: %v", err)
+		}
+	}
+	metrics.RegisterDBStats(repo.DB(), cfg.Database.DBName)
+
+	memCache := cache.NewMemoryCache(cacheLimits(cfg.Cache), logger)
+
+	metrics.RegisterCache(memCache.Stats)
 
 	logger.Info("Restoring cache from database...")
-	if err := memCache.LoadFromRepository(repo); err != nil {
+	if err := memCache.LoadFromRepository(ctx, repo); err != nil {
 		logger.Errorf("Failed to load cache from repository: %v", err)
+		go retryWarmUp(ctx, memCache, repo, cfg.Health.WarmUpRetryInterval, logger)
+	}
+
+	deadLetter, err := kafka.NewDeadLetterProducer(cfg.Kafka, logger)
+	if err != nil {
+		logger.Fatalf("Failed to create dead-letter producer: %v", err)
+	}
+	defer deadLetter.Close()
+
+	orderProducer, err := kafka.NewOrderProducer(cfg.Kafka, logger)
+	if err != nil {
+		logger.Fatalf("Failed to create order producer: %v", err)
 	}
+	defer orderProducer.Close()
 
-	consumer, err := kafka.NewConsumer(cfg.Kafka.Brokers, cfg.Kafka.GroupID, []string{cfg.Kafka.Topic}, logger)
+	eventProducer, err := kafka.NewEventProducer(cfg.Kafka, cfg.Outbox.Topic, logger)
+	if err != nil {
+		logger.Fatalf("Failed to create event producer: %v", err)
+	}
+	defer eventProducer.Close()
+
+	relay := outbox.NewRelay(repo, eventProducer, outbox.Options{
+		BatchSize:      cfg.Outbox.BatchSize,
+		PollInterval:   cfg.Outbox.PollInterval,
+		InitialBackoff: cfg.Outbox.InitialBackoff,
+		MaxBackoff:     cfg.Outbox.MaxBackoff,
+		Retention:      cfg.Outbox.Retention,
+	}, logger)
+
+	// The consumer and the HTTP write path share one validator so that a
+	// reload changes rule severities for both.
+	validator := models.NewOrderValidator()
+	decoder := models.NewDecoder(models.DecodeOptions{
+		UnknownFields: models.Severity(cfg.Decoding.UnknownFields),
+		MaxBytes:      cfg.Decoding.MaxMessageBytes,
+		MaxItems:      cfg.Decoding.MaxItems,
+	})
+
+	consumer, err := kafka.NewConsumer(cfg.Kafka, logger)
 	if err != nil {
 		logger.Fatalf("Failed to create Kafka consumer: %v", err)
 	}
 	defer consumer.Stop()
 
-	orderHandler := kafka.NewOrderHandler(repo, memCache, logger)
+	orderHandler := kafka.NewOrderHandler(repo, memCache.WithSource(cache.SourceKafka), logger)
 	consumer.AddHandler(orderHandler)
+	consumer.SetDeadLetterPublisher(deadLetter)
+	consumer.SetValidator(validator)
+	consumer.SetDecoder(decoder)
+	consumer.SetStallThreshold(cfg.Health.ConsumerStallThreshold)
+	consumer.SetRetryPolicy(kafka.RetryPolicy{
+		MaxAttempts:    cfg.Kafka.Retry.MaxAttempts,
+		InitialBackoff: cfg.Kafka.Retry.InitialBackoff,
+		MaxBackoff:     cfg.Kafka.Retry.MaxBackoff,
+		Multiplier:     2,
+	})
 
-	httpHandler := handlers.NewHTTPHandler(memCache, repo, logger)
+	checker := health.NewChecker(cfg.Health.CheckTimeout)
+	checker.Register("database", repo.HealthCheck)
+	checker.Register("kafka", consumer.HealthCheck)
+	checker.Register("cache", memCache.HealthCheck)
+
+	httpHandler := handlers.NewHTTPHandler(memCache.WithSource(cache.SourceHTTP), repo, checker, logger)
+	reloader := newConfigReloader(loader, cfg, runtimeSettings(logger, memCache, httpHandler, validator), logger)
+	httpHandler.SetReloader(reloader, cfg.Server.AdminToken)
+	httpHandler.SetPublisher(orderProducer)
+	httpHandler.SetValidator(validator)
+	httpHandler.SetDecoder(decoder)
 	router := httpHandler.SetupRoutes()
 
+	// Requests still running when the graceful shutdown deadline expires are
+	// cancelled through their base context, which aborts in-flight queries.
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
 	server := &http.Server{
-		Addr:         ":" + cfg.Server.Port,
+		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
 		Handler:      router,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
+		BaseContext: func(net.Listener) context.Context {
+			return requestsCtx
+		},
 	}
 
 	sigChan := make(chan os.Signal, 1)
@@ -74,6 +197,10 @@ func main() {
 
 	g, gCtx := errgroup.WithContext(ctx)
 
+	// The janitor always runs so that a TTL enabled by a reload is honoured.
+	go memCache.RunJanitor(gCtx, cfg.Cache.JanitorInterval)
+	go reloader.watchReload(gCtx)
+
 	g.Go(func() error {
 		logger.Info("Starting Kafka consumer...")
 
@@ -90,7 +217,12 @@ func main() {
 	})
 
 	g.Go(func() error {
-		logger.Infof("Starting HTTP server on port %s", cfg.Server.Port)
+		relay.Run(gCtx)
+		return nil
+	})
+
+	g.Go(func() error {
+		logger.Infof("Starting HTTP server on port %d", cfg.Server.Port)
 
 		go func() {
 			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -104,7 +236,9 @@ func main() {
 
 		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer shutdownCancel()
-		return server.Shutdown(shutdownCtx)
+		err := server.Shutdown(shutdownCtx)
+		cancelRequests()
+		return err
 	})
 
 	select {
@@ -123,3 +257,22 @@ func main() {
 
 	logger.Info("Service stopped gracefully")
 }
+
+func retryWarmUp(ctx context.Context, memCache *cache.MemoryCache, repo cache.OrderRepository,
+	interval time.Duration, logger *logrus.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := memCache.LoadFromRepository(ctx, repo); err != nil {
+				logger.Errorf("Failed to load cache from repository: %v", err)
+				continue
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"order-service/internal/migrate"
+	"order-service/internal/repository"
+	"order-service/migrations"
+
+	"github.com/sirupsen/logrus"
+)
+
+const migrateUsage = "usage: order-service migrate up | down [steps] | status"
+
+func runMigrateCommand(args []string, repo *repository.PostgresRepository, logger *logrus.Logger) error {
+	migrator, err := migrate.New(repo.DB(), migrations.FS, logger)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		logger.Infof("Applied %d migrations", applied)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		rolledBack, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		logger.Infof("Rolled back %d migrations", rolledBack)
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = "applied at " + status.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(os.Stdout, "%03d_%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	return nil
+}
+
+func applyMigrations(ctx context.Context, repo *repository.PostgresRepository, logger *logrus.Logger) error {
+	migrator, err := migrate.New(repo.DB(), migrations.FS, logger)
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Database schema is up to date (%d migrations applied)", applied)
+	return nil
+}
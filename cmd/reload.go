@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"order-service/internal/cache"
+	"order-service/internal/config"
+	"order-service/internal/handlers"
+	"order-service/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// configReloader re-reads the configuration on demand and applies the
+// settings that can change without a restart.
+type configReloader struct {
+	mu      sync.Mutex
+	loader  *config.Loader
+	current *config.Config
+	apply   func(cfg *config.Config)
+	log     *logrus.Logger
+}
+
+func newConfigReloader(loader *config.Loader, current *config.Config, apply func(cfg *config.Config),
+	logger *logrus.Logger) *configReloader {
+	apply(current)
+	return &configReloader{
+		loader:  loader,
+		current: current,
+		apply:   apply,
+		log:     logger,
+	}
+}
+
+// Reload loads the configuration again. An invalid configuration is
+// rejected as a whole and the running settings are kept.
+func (r *configReloader) Reload() (config.ReloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := r.loader.Load()
+	if err != nil {
+		return config.ReloadResult{}, err
+	}
+
+	applied := r.current.WithReloadable(next)
+	result := config.ReloadResult{
+		Applied:         config.Changes(r.current, applied),
+		RestartRequired: config.Changes(applied, next),
+	}
+
+	r.apply(applied)
+	r.current = applied
+
+	entry := r.log.WithField("applied", result.Applied)
+	if len(result.RestartRequired) > 0 {
+		entry.WithField("restart_required", result.RestartRequired).
+			Warn("Configuration reloaded, some changes require a restart")
+	} else {
+		entry.Info("Configuration reloaded")
+	}
+	return result, nil
+}
+
+// watchReload reloads the configuration on every SIGHUP until ctx is done.
+func (r *configReloader) watchReload(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.log.Info("Received SIGHUP, reloading configuration")
+			if _, err := r.Reload(); err != nil {
+				r.log.Errorf("Configuration reload failed, keeping current settings: %v", err)
+			}
+		}
+	}
+}
+
+func runtimeSettings(logger *logrus.Logger, memCache *cache.MemoryCache, httpHandler *handlers.HTTPHandler,
+	validator *models.OrderValidator) func(*config.Config) {
+	return func(cfg *config.Config) {
+		if level, err := logrus.ParseLevel(cfg.Log.Level); err == nil {
+			logger.SetLevel(level)
+		}
+		if err := validator.SetSeverities(cfg.Validation.Rules); err != nil {
+			logger.Errorf("Failed to apply validation rules: %v", err)
+		}
+		memCache.SetLimits(cacheLimits(cfg.Cache))
+		httpHandler.SetSettings(handlers.Settings{
+			AllowedOrigins: cfg.Server.CORSAllowedOrigins,
+			RateLimit:      cfg.Server.RateLimit.RequestsPerSecond,
+			RateBurst:      cfg.Server.RateLimit.Burst,
+			Features:       cfg.Features,
+		})
+	}
+}
+
+func cacheLimits(cfg config.CacheConfig) cache.Limits {
+	return cache.Limits{
+		MaxEntries: cfg.MaxEntries,
+		MaxBytes:   cfg.MaxBytes,
+		TTL:        cfg.TTL,
+	}
+}
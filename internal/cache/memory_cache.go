@@ -1,102 +1,328 @@
 package cache
 
 import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
 	"order-service/internal/models"
+	"order-service/internal/tracing"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var errCacheFull = errors.New("cache is full")
+
+// Limits bounds the cache. Zero values disable the corresponding limit.
+type Limits struct {
+	MaxEntries int
+	MaxBytes   int64
+	TTL        time.Duration
+}
+
+type entry struct {
+	orderUID  string
+	order     *models.Order
+	size      int64
+	expiresAt time.Time
+}
+
+// MemoryCache is an LRU cache of orders bounded by entry count and an
+// approximate byte budget, with an optional per-entry TTL.
 type MemoryCache struct {
-	orders map[string]*models.Order
-	mutex  sync.RWMutex
+	orders map[string]*list.Element
+	lru    *list.List
+	limits Limits
+	bytes  int64
+	stats  counters
+	mutex  sync.Mutex
 	log    *logrus.Logger
+
+	warmedUp  bool
+	warmUpErr error
 }
 
-func NewMemoryCache(logger *logrus.Logger) *MemoryCache {
+func NewMemoryCache(limits Limits, logger *logrus.Logger) *MemoryCache {
 	return &MemoryCache{
-		orders: make(map[string]*models.Order),
+		orders: make(map[string]*list.Element),
+		lru:    list.New(),
+		limits: limits,
+		stats:  newCounters(),
 		log:    logger,
 	}
 }
 
-func (c *MemoryCache) Set(orderUID string, order *models.Order) {
+// SetLimits replaces the cache limits at runtime, evicting entries that no
+// longer fit. A new TTL only applies to entries stored afterwards.
+func (c *MemoryCache) SetLimits(limits Limits) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.limits = limits
+	c.evictOverflow()
+}
+
+func (c *MemoryCache) Set(ctx context.Context, orderUID string, order *models.Order) {
+	c.setFrom(ctx, orderUID, order, "")
+}
+
+func (c *MemoryCache) setFrom(ctx context.Context, orderUID string, order *models.Order, source string) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	c.orders[orderUID] = order
-	c.log.Debugf("Order %s added to cache", orderUID)
+	c.set(orderUID, order)
+	if source != "" {
+		c.stats.source(source).Sets++
+	}
+	c.log.WithContext(ctx).Debugf("Order %s added to cache", orderUID)
+}
+
+func (c *MemoryCache) set(orderUID string, order *models.Order) {
+	e := &entry{
+		orderUID: orderUID,
+		order:    order,
+		size:     estimateSize(order),
+	}
+	if c.limits.TTL > 0 {
+		e.expiresAt = time.Now().Add(c.limits.TTL)
+	}
+
+	if elem, exists := c.orders[orderUID]; exists {
+		c.bytes -= elem.Value.(*entry).size
+		elem.Value = e
+		c.lru.MoveToFront(elem)
+	} else {
+		c.orders[orderUID] = c.lru.PushFront(e)
+	}
+	c.bytes += e.size
+	c.stats.sets++
+
+	c.evictOverflow()
+}
+
+func (c *MemoryCache) Get(ctx context.Context, orderUID string) (*models.Order, bool) {
+	return c.get(ctx, orderUID, "")
 }
 
-func (c *MemoryCache) Get(orderUID string) (*models.Order, bool) {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
+func (c *MemoryCache) get(ctx context.Context, orderUID string, source string) (order *models.Order, found bool) {
+	ctx, span := tracing.Start(ctx, "cache.Get", trace.WithAttributes(attribute.String("order_uid", orderUID)))
+	defer func() {
+		span.SetAttributes(attribute.Bool("cache.hit", found))
+		span.End()
+	}()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	log := c.log.WithContext(ctx)
 
-	order, exists := c.orders[orderUID]
-	if exists {
-		c.log.Debugf("Order %s found in cache", orderUID)
-		return order, true
+	elem, exists := c.orders[orderUID]
+	if exists && elem.Value.(*entry).expired(time.Now()) {
+		c.removeElement(elem)
+		c.stats.expirations++
+		log.Debugf("Order %s expired in cache", orderUID)
+		exists = false
 	}
 
-	c.log.Debugf("Order %s not found in cache", orderUID)
-	return nil, false
+	if !exists {
+		c.stats.misses++
+		if source != "" {
+			c.stats.source(source).Misses++
+		}
+		log.Debugf("Order %s not found in cache", orderUID)
+		return nil, false
+	}
+
+	c.stats.hits++
+	if source != "" {
+		c.stats.source(source).Hits++
+	}
+	c.lru.MoveToFront(elem)
+	log.Debugf("Order %s found in cache", orderUID)
+	return elem.Value.(*entry).order, true
 }
 
-func (c *MemoryCache) Delete(orderUID string) {
+func (c *MemoryCache) Delete(ctx context.Context, orderUID string) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	delete(c.orders, orderUID)
-	c.log.Debugf("Order %s deleted from cache", orderUID)
+	if elem, exists := c.orders[orderUID]; exists {
+		c.removeElement(elem)
+		c.stats.deletes++
+	}
+	c.log.WithContext(ctx).Debugf("Order %s deleted from cache", orderUID)
 }
 
 func (c *MemoryCache) Clear() {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	c.orders = make(map[string]*models.Order)
+	c.orders = make(map[string]*list.Element)
+	c.lru.Init()
+	c.bytes = 0
 	c.log.Debug("Cache cleared")
 }
 
-func (c *MemoryCache) LoadFromRepository(repo OrderRepository) error {
+// LoadFromRepository warms the cache with the newest orders until the
+// repository is exhausted or the cache limits are reached.
+func (c *MemoryCache) LoadFromRepository(ctx context.Context, repo OrderRepository) error {
 	c.log.Info("Loading orders from repository to cache...")
 
-	orders, err := repo.GetAllOrders()
-	if err != nil {
+	started := time.Now()
+	loaded := 0
+	err := repo.ForEachOrder(ctx, func(order *models.Order) error {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+
+		if c.full() {
+			return errCacheFull
+		}
+		c.set(order.OrderUID, order)
+		c.stats.source(sourceLoad).Sets++
+
+		loaded++
+		return nil
+	})
+	if err != nil && err != errCacheFull {
+		c.mutex.Lock()
+		c.warmUpErr = err
+		c.mutex.Unlock()
 		return err
 	}
 
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	c.stats.loadedOrders = loaded
+	c.stats.loadDuration = time.Since(started)
+	c.stats.lastWarmUp = time.Now()
+	c.warmedUp = true
+	c.warmUpErr = nil
+	c.mutex.Unlock()
 
-	for _, order := range orders {
-		c.orders[order.OrderUID] = order
+	if err == errCacheFull {
+		c.log.Infof("Cache limits reached, loaded %d most recent orders into cache", loaded)
+		return nil
 	}
 
-	c.log.Infof("Loaded %d orders into cache", len(orders))
+	c.log.Infof("Loaded %d orders into cache", loaded)
 	return nil
 }
 
+// HealthCheck reports whether the initial warm-up from the repository has
+// completed successfully.
+func (c *MemoryCache) HealthCheck(ctx context.Context) (map[string]interface{}, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	details := map[string]interface{}{
+		"warmed_up":  c.warmedUp,
+		"cache_size": len(c.orders),
+	}
+	if c.warmedUp {
+		details["last_warm_up"] = c.stats.lastWarmUp
+		return details, nil
+	}
+	if c.warmUpErr != nil {
+		return details, fmt.Errorf("cache warm-up failed: %w", c.warmUpErr)
+	}
+	return details, errors.New("cache warm-up has not completed")
+}
+
 func (c *MemoryCache) Size() int {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 
 	return len(c.orders)
 }
 
+func (c *MemoryCache) Bytes() int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.bytes
+}
+
 func (c *MemoryCache) GetAll() map[string]*models.Order {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 
-	result := make(map[string]*models.Order)
-	for k, v := range c.orders {
-		result[k] = v
+	now := time.Now()
+	result := make(map[string]*models.Order, len(c.orders))
+	for k, elem := range c.orders {
+		if e := elem.Value.(*entry); !e.expired(now) {
+			result[k] = e.order
+		}
 	}
 
 	return result
 }
 
+func (c *MemoryCache) PurgeExpired() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	purged := 0
+	for _, elem := range c.orders {
+		if elem.Value.(*entry).expired(now) {
+			c.removeElement(elem)
+			c.stats.expirations++
+			purged++
+		}
+	}
+
+	if purged > 0 {
+		c.log.Debugf("Purged %d expired orders from cache", purged)
+	}
+	return purged
+}
+
+// RunJanitor periodically purges expired entries until ctx is cancelled.
+func (c *MemoryCache) RunJanitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.PurgeExpired()
+		}
+	}
+}
+
+func (c *MemoryCache) full() bool {
+	return (c.limits.MaxEntries > 0 && len(c.orders) >= c.limits.MaxEntries) ||
+		(c.limits.MaxBytes > 0 && c.bytes >= c.limits.MaxBytes)
+}
+
+func (c *MemoryCache) evictOverflow() {
+	for c.lru.Len() > 1 &&
+		((c.limits.MaxEntries > 0 && len(c.orders) > c.limits.MaxEntries) ||
+			(c.limits.MaxBytes > 0 && c.bytes > c.limits.MaxBytes)) {
+		oldest := c.lru.Back()
+		c.removeElement(oldest)
+		c.stats.evictions++
+		c.log.Debugf("Order %s evicted from cache", oldest.Value.(*entry).orderUID)
+	}
+}
+
+func (c *MemoryCache) removeElement(elem *list.Element) {
+	e := elem.Value.(*entry)
+	c.lru.Remove(elem)
+	delete(c.orders, e.orderUID)
+	c.bytes -= e.size
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
 type OrderRepository interface {
-	GetAllOrders() ([]*models.Order, error)
-	GetOrder(orderUID string) (*models.Order, error)
+	ForEachOrder(ctx context.Context, fn func(order *models.Order) error) error
+	GetOrder(ctx context.Context, orderUID string) (*models.Order, error)
 }
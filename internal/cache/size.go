@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"order-service/internal/models"
+	"unsafe"
+)
+
+const entryOverhead = int64(unsafe.Sizeof(entry{})) + 64
+
+// estimateSize approximates the heap footprint of an order: the struct sizes
+// plus the bytes held by its strings. It is cheap and deliberately rough.
+func estimateSize(order *models.Order) int64 {
+	size := entryOverhead + int64(unsafe.Sizeof(*order)) + int64(len(order.OrderUID))*2
+	size += int64(len(order.OrderUID) + len(order.TrackNumber) + len(order.Entry) +
+		len(order.Locale) + len(order.InternalSignature) + len(order.CustomerID) +
+		len(order.DeliveryService) + len(order.ShardKey) + len(order.OofShard))
+
+	d := order.Delivery
+	size += int64(len(d.Name) + len(d.Phone) + len(d.Zip) + len(d.City) +
+		len(d.Address) + len(d.Region) + len(d.Email))
+
+	p := order.Payment
+	size += int64(len(p.Transaction) + len(p.RequestID) + len(p.Currency) +
+		len(p.Provider) + len(p.Bank))
+
+	size += int64(cap(order.Items)) * int64(unsafe.Sizeof(models.Item{}))
+	for _, item := range order.Items {
+		size += int64(len(item.TrackNumber) + len(item.Rid) + len(item.Name) +
+			len(item.Size) + len(item.Brand))
+	}
+
+	return size
+}
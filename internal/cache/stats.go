@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"context"
+	"order-service/internal/models"
+	"time"
+)
+
+const (
+	SourceKafka = "kafka"
+	SourceHTTP  = "http"
+	sourceLoad  = "warmup"
+)
+
+type SourceStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	Sets   uint64 `json:"sets"`
+}
+
+type Stats struct {
+	Size           int                    `json:"cache_size"`
+	Bytes          int64                  `json:"cache_bytes"`
+	MaxEntries     int                    `json:"max_entries"`
+	MaxBytes       int64                  `json:"max_bytes"`
+	TTL            string                 `json:"ttl"`
+	Hits           uint64                 `json:"hits"`
+	Misses         uint64                 `json:"misses"`
+	HitRatio       float64                `json:"hit_ratio"`
+	Sets           uint64                 `json:"sets"`
+	Deletes        uint64                 `json:"deletes"`
+	Evictions      uint64                 `json:"evictions"`
+	Expirations    uint64                 `json:"expirations"`
+	LoadedOrders   int                    `json:"loaded_orders"`
+	LoadDuration   string                 `json:"load_duration"`
+	LoadDurationMs int64                  `json:"load_duration_ms"`
+	LastWarmUp     *time.Time             `json:"last_warm_up,omitempty"`
+	Sources        map[string]SourceStats `json:"sources"`
+}
+
+type counters struct {
+	hits         uint64
+	misses       uint64
+	sets         uint64
+	deletes      uint64
+	evictions    uint64
+	expirations  uint64
+	loadedOrders int
+	loadDuration time.Duration
+	lastWarmUp   time.Time
+	sources      map[string]*SourceStats
+}
+
+func newCounters() counters {
+	return counters{sources: make(map[string]*SourceStats)}
+}
+
+func (c *counters) source(name string) *SourceStats {
+	stats, ok := c.sources[name]
+	if !ok {
+		stats = &SourceStats{}
+		c.sources[name] = stats
+	}
+	return stats
+}
+
+func (c *MemoryCache) Stats() Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stats := Stats{
+		Size:           len(c.orders),
+		Bytes:          c.bytes,
+		MaxEntries:     c.limits.MaxEntries,
+		MaxBytes:       c.limits.MaxBytes,
+		TTL:            c.limits.TTL.String(),
+		Hits:           c.stats.hits,
+		Misses:         c.stats.misses,
+		Sets:           c.stats.sets,
+		Deletes:        c.stats.deletes,
+		Evictions:      c.stats.evictions,
+		Expirations:    c.stats.expirations,
+		LoadedOrders:   c.stats.loadedOrders,
+		LoadDuration:   c.stats.loadDuration.String(),
+		LoadDurationMs: c.stats.loadDuration.Milliseconds(),
+		Sources:        make(map[string]SourceStats, len(c.stats.sources)),
+	}
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(lookups)
+	}
+	if !c.stats.lastWarmUp.IsZero() {
+		lastWarmUp := c.stats.lastWarmUp
+		stats.LastWarmUp = &lastWarmUp
+	}
+	for name, source := range c.stats.sources {
+		stats.Sources[name] = *source
+	}
+
+	return stats
+}
+
+// SourceView is a handle on the shared cache that attributes reads and
+// writes to one source, so Kafka writes and HTTP read-through can be told
+// apart in the statistics.
+type SourceView struct {
+	cache  *MemoryCache
+	source string
+}
+
+func (c *MemoryCache) WithSource(source string) *SourceView {
+	return &SourceView{cache: c, source: source}
+}
+
+func (v *SourceView) Get(ctx context.Context, orderUID string) (*models.Order, bool) {
+	return v.cache.get(ctx, orderUID, v.source)
+}
+
+func (v *SourceView) Set(ctx context.Context, orderUID string, order *models.Order) {
+	v.cache.setFrom(ctx, orderUID, order, v.source)
+}
+
+func (v *SourceView) Delete(ctx context.Context, orderUID string) {
+	v.cache.Delete(ctx, orderUID)
+}
+
+func (v *SourceView) Size() int {
+	return v.cache.Size()
+}
+
+func (v *SourceView) Stats() Stats {
+	return v.cache.Stats()
+}
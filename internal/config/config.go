@@ -1,68 +1,276 @@
 package config
 
 import (
-	"os"
-	"strconv"
+	"time"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	Kafka    KafkaConfig
-	Server   ServerConfig
+	Log        LogConfig        `yaml:"log"`
+	Database   DatabaseConfig   `yaml:"database"`
+	Kafka      KafkaConfig      `yaml:"kafka"`
+	Server     ServerConfig     `yaml:"server"`
+	Cache      CacheConfig      `yaml:"cache"`
+	Health     HealthConfig     `yaml:"health"`
+	Features   FeaturesConfig   `yaml:"features"`
+	Outbox     OutboxConfig     `yaml:"outbox"`
+	Tracing    TracingConfig    `yaml:"tracing"`
+	Validation ValidationConfig `yaml:"validation"`
+	Decoding   DecodingConfig   `yaml:"decoding"`
+}
+
+type LogConfig struct {
+	Level string `yaml:"level"`
 }
 
 type DatabaseConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Host        string           `yaml:"host"`
+	Port        int              `yaml:"port"`
+	User        string           `yaml:"user"`
+	Password    string           `yaml:"password"`
+	DBName      string           `yaml:"dbname"`
+	SSLMode     string           `yaml:"sslmode"`
+	AutoMigrate bool             `yaml:"auto_migrate"`
+	Timeouts    DatabaseTimeouts `yaml:"timeouts"`
+
+	ApplicationName  string            `yaml:"application_name"`
+	ConnectTimeout   time.Duration     `yaml:"connect_timeout"`
+	StatementTimeout time.Duration     `yaml:"statement_timeout"`
+	SSLRootCert      string            `yaml:"sslrootcert"`
+	SSLCert          string            `yaml:"sslcert"`
+	SSLKey           string            `yaml:"sslkey"`
+	Params           map[string]string `yaml:"params"`
+
+	Pool    PoolConfig  `yaml:"pool"`
+	Connect RetryConfig `yaml:"connect"`
+}
+
+type PoolConfig struct {
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+}
+
+type DatabaseTimeouts struct {
+	Save   time.Duration `yaml:"save"`
+	Get    time.Duration `yaml:"get"`
+	Load   time.Duration `yaml:"load_batch"`
+	Search time.Duration `yaml:"search"`
 }
 
 type KafkaConfig struct {
-	Brokers []string
-	Topic   string
-	GroupID string
+	Brokers           []string        `yaml:"brokers"`
+	Topics            []string        `yaml:"topics"`
+	GroupID           string          `yaml:"group_id"`
+	ClientID          string          `yaml:"client_id"`
+	Version           string          `yaml:"version"`
+	InitialOffset     string          `yaml:"initial_offset"`
+	RebalanceStrategy string          `yaml:"rebalance_strategy"`
+	SessionTimeout    time.Duration   `yaml:"session_timeout"`
+	HeartbeatInterval time.Duration   `yaml:"heartbeat_interval"`
+	MaxProcessingTime time.Duration   `yaml:"max_processing_time"`
+	FetchMinBytes     int             `yaml:"fetch_min_bytes"`
+	FetchDefaultBytes int             `yaml:"fetch_default_bytes"`
+	FetchMaxBytes     int             `yaml:"fetch_max_bytes"`
+	IsolationLevel    string          `yaml:"isolation_level"`
+	DeadLetterTopic   string          `yaml:"dlq_topic"`
+	ProduceTopic      string          `yaml:"produce_topic"`
+	Retry             RetryConfig     `yaml:"retry"`
+	SASL              KafkaSASLConfig `yaml:"sasl"`
+	TLS               KafkaTLSConfig  `yaml:"tls"`
+}
+
+type KafkaSASLConfig struct {
+	Mechanism string `yaml:"mechanism"`
+	Username  string `yaml:"username"`
+	Password  string `yaml:"password"`
+}
+
+type KafkaTLSConfig struct {
+	Enabled            bool   `yaml:"enabled"`
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	ServerName         string `yaml:"server_name"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+type RetryConfig struct {
+	MaxAttempts    int           `yaml:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+}
+
+type CacheConfig struct {
+	MaxEntries      int           `yaml:"max_entries"`
+	MaxBytes        int64         `yaml:"max_bytes"`
+	TTL             time.Duration `yaml:"ttl"`
+	JanitorInterval time.Duration `yaml:"janitor_interval"`
+}
+
+type HealthConfig struct {
+	CheckTimeout        time.Duration `yaml:"check_timeout"`
+	WarmUpRetryInterval time.Duration `yaml:"warmup_retry_interval"`
+	// ConsumerStallThreshold fails readiness when the consumer has lag but
+	// received no message for this long. Zero disables the check.
+	ConsumerStallThreshold time.Duration `yaml:"consumer_stall_threshold"`
 }
 
 type ServerConfig struct {
-	Port string
+	Port               int             `yaml:"port"`
+	CORSAllowedOrigins []string        `yaml:"cors_allowed_origins"`
+	RateLimit          RateLimitConfig `yaml:"rate_limit"`
+	AdminToken         string          `yaml:"admin_token"`
 }
 
-func LoadConfig() *Config {
+// RateLimitConfig limits API requests with a token bucket. A zero rate
+// disables limiting.
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	Burst             int     `yaml:"burst"`
+}
+
+// OutboxConfig controls the relay that publishes order-change events.
+type OutboxConfig struct {
+	Topic          string        `yaml:"topic"`
+	BatchSize      int           `yaml:"batch_size"`
+	PollInterval   time.Duration `yaml:"poll_interval"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+	Retention      time.Duration `yaml:"retention"`
+}
+
+const (
+	TracingExporterNone   = "none"
+	TracingExporterOTLP   = "otlp"
+	TracingExporterStdout = "stdout"
+	TracingExporterFile   = "file"
+)
+
+// TracingConfig selects where OpenTelemetry spans are exported. Endpoint
+// is the host:port of an OTLP/HTTP collector; File is used by the file
+// exporter.
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`
+	Insecure    bool    `yaml:"insecure"`
+	File        string  `yaml:"file"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+	ServiceName string  `yaml:"service_name"`
+}
+
+// ValidationConfig overrides the severity, reject or warn, of order
+// validation rules by rule name.
+type ValidationConfig struct {
+	Rules map[string]string `yaml:"rules"`
+}
+
+// DecodingConfig controls how strictly Kafka messages and HTTP bodies are
+// decoded. UnknownFields is reject or warn; zero limits disable the checks.
+type DecodingConfig struct {
+	UnknownFields   string `yaml:"unknown_fields"`
+	MaxMessageBytes int    `yaml:"max_message_bytes"`
+	MaxItems        int    `yaml:"max_items"`
+}
+
+type FeaturesConfig struct {
+	WebUI      bool `yaml:"web_ui"`
+	Search     bool `yaml:"search"`
+	CacheStats bool `yaml:"cache_stats"`
+}
+
+// Default returns the built-in configuration that the config file,
+// environment variables and flags are layered on top of.
+func Default() *Config {
 	return &Config{
+		Log: LogConfig{
+			Level: "info",
+		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "orders_user"),
-			Password: getEnv("DB_PASSWORD", "orders_password"),
-			DBName:   getEnv("DB_NAME", "orders_db"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Host:        "localhost",
+			Port:        5432,
+			User:        "orders_user",
+			DBName:      "orders_db",
+			SSLMode:     "disable",
+			AutoMigrate: true,
+			Timeouts: DatabaseTimeouts{
+				Save:   5 * time.Second,
+				Get:    3 * time.Second,
+				Load:   30 * time.Second,
+				Search: 5 * time.Second,
+			},
+			ApplicationName: "order-service",
+			ConnectTimeout:  5 * time.Second,
+			Pool: PoolConfig{
+				MaxOpenConns:    25,
+				MaxIdleConns:    10,
+				ConnMaxLifetime: 30 * time.Minute,
+				ConnMaxIdleTime: 5 * time.Minute,
+			},
+			Connect: RetryConfig{
+				MaxAttempts:    10,
+				InitialBackoff: time.Second,
+				MaxBackoff:     10 * time.Second,
+			},
 		},
 		Kafka: KafkaConfig{
-			Brokers: []string{getEnv("KAFKA_BROKERS", "localhost:9092")},
-			Topic:   getEnv("KAFKA_TOPIC", "orders"),
-			GroupID: getEnv("KAFKA_GROUP_ID", "order-service"),
+			Brokers:           []string{"localhost:9092"},
+			Topics:            []string{"orders"},
+			GroupID:           "order-service",
+			ClientID:          "order-service",
+			Version:           "2.8.0",
+			InitialOffset:     OffsetOldest,
+			RebalanceStrategy: RebalanceRoundRobin,
+			SessionTimeout:    10 * time.Second,
+			HeartbeatInterval: 3 * time.Second,
+			MaxProcessingTime: time.Second,
+			FetchMinBytes:     1,
+			FetchDefaultBytes: 1 << 20,
+			IsolationLevel:    IsolationReadUncommitted,
+			DeadLetterTopic:   "orders-dlq",
+			Retry: RetryConfig{
+				MaxAttempts:    5,
+				InitialBackoff: 200 * time.Millisecond,
+				MaxBackoff:     5 * time.Second,
+			},
 		},
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8081"),
+			Port:               8081,
+			CORSAllowedOrigins: []string{"*"},
+		},
+		Cache: CacheConfig{
+			MaxEntries:      100000,
+			MaxBytes:        256 << 20,
+			JanitorInterval: time.Minute,
+		},
+		Health: HealthConfig{
+			CheckTimeout:           2 * time.Second,
+			WarmUpRetryInterval:    10 * time.Second,
+			ConsumerStallThreshold: 2 * time.Minute,
+		},
+		Features: FeaturesConfig{
+			WebUI:      true,
+			Search:     true,
+			CacheStats: true,
+		},
+		Outbox: OutboxConfig{
+			Topic:          "order-events",
+			BatchSize:      100,
+			PollInterval:   time.Second,
+			InitialBackoff: time.Second,
+			MaxBackoff:     time.Minute,
+			Retention:      24 * time.Hour,
+		},
+		Tracing: TracingConfig{
+			Exporter:    TracingExporterNone,
+			Endpoint:    "localhost:4318",
+			SampleRatio: 1,
+			ServiceName: "order-service",
+		},
+		Decoding: DecodingConfig{
+			UnknownFields:   "warn",
+			MaxMessageBytes: 1 << 20,
+			MaxItems:        1000,
 		},
 	}
 }
-
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
-
-func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
-		}
-	}
-	return defaultValue
-}
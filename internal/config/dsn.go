@@ -0,0 +1,85 @@
+package config
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DSN builds a libpq key/value connection string. Values are quoted when
+// they contain spaces, quotes or backslashes. Entries of Params are the
+// most explicit and win over the typed settings, some of which, such as
+// application_name, always have defaults.
+func (c DatabaseConfig) DSN() string {
+	params := map[string]string{
+		"host":     c.Host,
+		"port":     strconv.Itoa(c.Port),
+		"user":     c.User,
+		"password": c.Password,
+		"dbname":   c.DBName,
+		"sslmode":  c.SSLMode,
+	}
+
+	optional := map[string]string{
+		"application_name": c.ApplicationName,
+		"sslrootcert":      c.SSLRootCert,
+		"sslcert":          c.SSLCert,
+		"sslkey":           c.SSLKey,
+	}
+	if c.ConnectTimeout > 0 {
+		optional["connect_timeout"] = strconv.FormatInt(ceilUnits(c.ConnectTimeout, time.Second), 10)
+	}
+	if c.StatementTimeout > 0 {
+		optional["statement_timeout"] = strconv.FormatInt(ceilUnits(c.StatementTimeout, time.Millisecond), 10)
+	}
+	for key, value := range optional {
+		if value != "" {
+			params[key] = value
+		}
+	}
+	for key, value := range c.Params {
+		params[key] = value
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key+"="+quoteDSNValue(params[key]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// ceilUnits converts a positive duration to whole units, rounding up: the
+// server reads 0 as no timeout, so 500ms must not become 0 seconds.
+func ceilUnits(d, unit time.Duration) int64 {
+	return int64((d + unit - 1) / unit)
+}
+
+func quoteDSNValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " '\\\t\n") {
+		return value
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + replacer.Replace(value) + "'"
+}
+
+// parseParams parses "key=value,key=value" lists used for extra DSN
+// parameters.
+func parseParams(value string) map[string]string {
+	params := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			continue
+		}
+		params[strings.TrimSpace(key)] = strings.TrimSpace(val)
+	}
+	return params
+}
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parser converts raw string values into typed fields and collects every
+// conversion failure instead of silently falling back to defaults.
+type parser struct {
+	source string
+	errs   []error
+}
+
+func (p *parser) fail(key, value, kind string) {
+	p.errs = append(p.errs, fmt.Errorf("%s%s: %q is not a valid %s", p.source, key, value, kind))
+}
+
+func (p *parser) integer(key, value string, target *int) {
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		p.fail(key, value, "integer")
+		return
+	}
+	*target = parsed
+}
+
+func (p *parser) integer64(key, value string, target *int64) {
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		p.fail(key, value, "integer")
+		return
+	}
+	*target = parsed
+}
+
+func (p *parser) float(key, value string, target *float64) {
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		p.fail(key, value, "number")
+		return
+	}
+	*target = parsed
+}
+
+func (p *parser) duration(key, value string, target *time.Duration) {
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		p.fail(key, value, "duration")
+		return
+	}
+	*target = parsed
+}
+
+func (p *parser) boolean(key, value string, target *bool) {
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		p.fail(key, value, "boolean")
+		return
+	}
+	*target = parsed
+}
+
+func (p *parser) err() error {
+	return errors.Join(p.errs...)
+}
+
+// envLoader overrides configuration fields with environment variables that
+// are set; unset variables leave the current value untouched.
+type envLoader struct {
+	parser
+}
+
+func (e *envLoader) str(key string, target *string) {
+	if value := os.Getenv(key); value != "" {
+		*target = value
+	}
+}
+
+func (e *envLoader) list(key string, target *[]string) {
+	if value := os.Getenv(key); value != "" {
+		*target = splitList(value)
+	}
+}
+
+func (e *envLoader) integer(key string, target *int) {
+	if value := os.Getenv(key); value != "" {
+		e.parser.integer(key, value, target)
+	}
+}
+
+func (e *envLoader) integer64(key string, target *int64) {
+	if value := os.Getenv(key); value != "" {
+		e.parser.integer64(key, value, target)
+	}
+}
+
+func (e *envLoader) float(key string, target *float64) {
+	if value := os.Getenv(key); value != "" {
+		e.parser.float(key, value, target)
+	}
+}
+
+func (e *envLoader) duration(key string, target *time.Duration) {
+	if value := os.Getenv(key); value != "" {
+		e.parser.duration(key, value, target)
+	}
+}
+
+func (e *envLoader) boolean(key string, target *bool) {
+	if value := os.Getenv(key); value != "" {
+		e.parser.boolean(key, value, target)
+	}
+}
+
+// secret reads KEY, or the file named by KEY_FILE, which is how container
+// orchestrators usually mount secrets.
+func (e *envLoader) secret(key string, target *string) {
+	path := os.Getenv(key + "_FILE")
+	if path == "" {
+		e.str(key, target)
+		return
+	}
+
+	if os.Getenv(key) != "" {
+		e.errs = append(e.errs, fmt.Errorf("only one of %s and %s_FILE may be set", key, key))
+		return
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("failed to read %s_FILE: %w", key, err))
+		return
+	}
+	*target = strings.TrimRight(string(content), "\r\n")
+}
+
+func applyEnv(cfg *Config) error {
+	e := &envLoader{}
+
+	e.str("LOG_LEVEL", &cfg.Log.Level)
+
+	db := &cfg.Database
+	e.str("DB_HOST", &db.Host)
+	e.integer("DB_PORT", &db.Port)
+	e.str("DB_USER", &db.User)
+	e.secret("DB_PASSWORD", &db.Password)
+	e.str("DB_NAME", &db.DBName)
+	e.str("DB_SSLMODE", &db.SSLMode)
+	e.boolean("DB_AUTO_MIGRATE", &db.AutoMigrate)
+	e.duration("DB_SAVE_TIMEOUT", &db.Timeouts.Save)
+	e.duration("DB_GET_TIMEOUT", &db.Timeouts.Get)
+	e.duration("DB_LOAD_BATCH_TIMEOUT", &db.Timeouts.Load)
+	e.duration("DB_SEARCH_TIMEOUT", &db.Timeouts.Search)
+	e.str("DB_APPLICATION_NAME", &db.ApplicationName)
+	e.duration("DB_CONNECT_TIMEOUT", &db.ConnectTimeout)
+	e.duration("DB_STATEMENT_TIMEOUT", &db.StatementTimeout)
+	e.str("DB_SSLROOTCERT", &db.SSLRootCert)
+	e.str("DB_SSLCERT", &db.SSLCert)
+	e.str("DB_SSLKEY", &db.SSLKey)
+	if value := os.Getenv("DB_PARAMS"); value != "" {
+		db.Params = parseParams(value)
+	}
+	e.integer("DB_MAX_OPEN_CONNS", &db.Pool.MaxOpenConns)
+	e.integer("DB_MAX_IDLE_CONNS", &db.Pool.MaxIdleConns)
+	e.duration("DB_CONN_MAX_LIFETIME", &db.Pool.ConnMaxLifetime)
+	e.duration("DB_CONN_MAX_IDLE_TIME", &db.Pool.ConnMaxIdleTime)
+	e.integer("DB_CONNECT_MAX_ATTEMPTS", &db.Connect.MaxAttempts)
+	e.duration("DB_CONNECT_INITIAL_BACKOFF", &db.Connect.InitialBackoff)
+	e.duration("DB_CONNECT_MAX_BACKOFF", &db.Connect.MaxBackoff)
+
+	kafka := &cfg.Kafka
+	e.list("KAFKA_BROKERS", &kafka.Brokers)
+	e.list("KAFKA_TOPIC", &kafka.Topics)
+	e.list("KAFKA_TOPICS", &kafka.Topics)
+	e.str("KAFKA_GROUP_ID", &kafka.GroupID)
+	e.str("KAFKA_CLIENT_ID", &kafka.ClientID)
+	e.str("KAFKA_VERSION", &kafka.Version)
+	e.str("KAFKA_INITIAL_OFFSET", &kafka.InitialOffset)
+	e.str("KAFKA_REBALANCE_STRATEGY", &kafka.RebalanceStrategy)
+	e.duration("KAFKA_SESSION_TIMEOUT", &kafka.SessionTimeout)
+	e.duration("KAFKA_HEARTBEAT_INTERVAL", &kafka.HeartbeatInterval)
+	e.duration("KAFKA_MAX_PROCESSING_TIME", &kafka.MaxProcessingTime)
+	e.integer("KAFKA_FETCH_MIN_BYTES", &kafka.FetchMinBytes)
+	e.integer("KAFKA_FETCH_DEFAULT_BYTES", &kafka.FetchDefaultBytes)
+	e.integer("KAFKA_FETCH_MAX_BYTES", &kafka.FetchMaxBytes)
+	e.str("KAFKA_ISOLATION_LEVEL", &kafka.IsolationLevel)
+	e.str("KAFKA_DLQ_TOPIC", &kafka.DeadLetterTopic)
+	e.str("KAFKA_PRODUCE_TOPIC", &kafka.ProduceTopic)
+	e.integer("KAFKA_RETRY_MAX_ATTEMPTS", &kafka.Retry.MaxAttempts)
+	e.duration("KAFKA_RETRY_INITIAL_BACKOFF", &kafka.Retry.InitialBackoff)
+	e.duration("KAFKA_RETRY_MAX_BACKOFF", &kafka.Retry.MaxBackoff)
+	e.str("KAFKA_SASL_MECHANISM", &kafka.SASL.Mechanism)
+	e.secret("KAFKA_SASL_USERNAME", &kafka.SASL.Username)
+	e.secret("KAFKA_SASL_PASSWORD", &kafka.SASL.Password)
+	e.boolean("KAFKA_TLS_ENABLED", &kafka.TLS.Enabled)
+	e.str("KAFKA_TLS_CA_FILE", &kafka.TLS.CAFile)
+	e.str("KAFKA_TLS_CERT_FILE", &kafka.TLS.CertFile)
+	e.str("KAFKA_TLS_KEY_FILE", &kafka.TLS.KeyFile)
+	e.str("KAFKA_TLS_SERVER_NAME", &kafka.TLS.ServerName)
+	e.boolean("KAFKA_TLS_INSECURE_SKIP_VERIFY", &kafka.TLS.InsecureSkipVerify)
+
+	e.integer("SERVER_PORT", &cfg.Server.Port)
+	e.list("SERVER_CORS_ALLOWED_ORIGINS", &cfg.Server.CORSAllowedOrigins)
+	e.float("SERVER_RATE_LIMIT_RPS", &cfg.Server.RateLimit.RequestsPerSecond)
+	e.integer("SERVER_RATE_LIMIT_BURST", &cfg.Server.RateLimit.Burst)
+	e.secret("ADMIN_TOKEN", &cfg.Server.AdminToken)
+
+	e.integer("CACHE_MAX_ENTRIES", &cfg.Cache.MaxEntries)
+	e.integer64("CACHE_MAX_BYTES", &cfg.Cache.MaxBytes)
+	e.duration("CACHE_TTL", &cfg.Cache.TTL)
+	e.duration("CACHE_JANITOR_INTERVAL", &cfg.Cache.JanitorInterval)
+
+	e.duration("HEALTH_CHECK_TIMEOUT", &cfg.Health.CheckTimeout)
+	e.duration("CACHE_WARMUP_RETRY_INTERVAL", &cfg.Health.WarmUpRetryInterval)
+	e.duration("HEALTH_CONSUMER_STALL_THRESHOLD", &cfg.Health.ConsumerStallThreshold)
+
+	e.boolean("FEATURE_WEB_UI", &cfg.Features.WebUI)
+	e.boolean("FEATURE_SEARCH", &cfg.Features.Search)
+	e.boolean("FEATURE_CACHE_STATS", &cfg.Features.CacheStats)
+
+	e.str("OUTBOX_TOPIC", &cfg.Outbox.Topic)
+	e.integer("OUTBOX_BATCH_SIZE", &cfg.Outbox.BatchSize)
+	e.duration("OUTBOX_POLL_INTERVAL", &cfg.Outbox.PollInterval)
+	e.duration("OUTBOX_INITIAL_BACKOFF", &cfg.Outbox.InitialBackoff)
+	e.duration("OUTBOX_MAX_BACKOFF", &cfg.Outbox.MaxBackoff)
+	e.duration("OUTBOX_RETENTION", &cfg.Outbox.Retention)
+
+	e.str("TRACING_EXPORTER", &cfg.Tracing.Exporter)
+	e.str("TRACING_OTLP_ENDPOINT", &cfg.Tracing.Endpoint)
+	e.boolean("TRACING_OTLP_INSECURE", &cfg.Tracing.Insecure)
+	e.str("TRACING_FILE", &cfg.Tracing.File)
+	e.float("TRACING_SAMPLE_RATIO", &cfg.Tracing.SampleRatio)
+	e.str("TRACING_SERVICE_NAME", &cfg.Tracing.ServiceName)
+
+	if value := os.Getenv("VALIDATION_RULES"); value != "" {
+		cfg.Validation.Rules = parseParams(value)
+	}
+
+	e.str("DECODING_UNKNOWN_FIELDS", &cfg.Decoding.UnknownFields)
+	e.integer("DECODING_MAX_MESSAGE_BYTES", &cfg.Decoding.MaxMessageBytes)
+	e.integer("DECODING_MAX_ITEMS", &cfg.Decoding.MaxItems)
+
+	return e.err()
+}
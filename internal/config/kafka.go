@@ -0,0 +1,156 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	OffsetOldest = "oldest"
+	OffsetNewest = "newest"
+
+	RebalanceRange             = "range"
+	RebalanceRoundRobin        = "roundrobin"
+	RebalanceSticky            = "sticky"
+	RebalanceCooperativeSticky = "cooperative-sticky"
+
+	IsolationReadUncommitted = "read_uncommitted"
+	IsolationReadCommitted   = "read_committed"
+
+	SASLPlain       = "PLAIN"
+	SASLScramSHA256 = "SCRAM-SHA-256"
+	SASLScramSHA512 = "SCRAM-SHA-512"
+)
+
+var kafkaVersionPattern = regexp.MustCompile(`^\d+\.\d+\.\d+(\.\d+)?$`)
+
+func (c KafkaConfig) Validate() error {
+	var errs []error
+
+	if len(c.Brokers) == 0 {
+		errs = append(errs, errors.New("at least one broker is required"))
+	}
+	for _, broker := range c.Brokers {
+		if !strings.Contains(broker, ":") {
+			errs = append(errs, fmt.Errorf("broker %q must be in host:port form", broker))
+		}
+	}
+	if len(c.Topics) == 0 {
+		errs = append(errs, errors.New("at least one topic is required"))
+	}
+	if c.GroupID == "" {
+		errs = append(errs, errors.New("group id is required"))
+	}
+	if c.Version != "" && !kafkaVersionPattern.MatchString(c.Version) {
+		errs = append(errs, fmt.Errorf("version %q must look like 2.8.0", c.Version))
+	}
+
+	switch c.InitialOffset {
+	case OffsetOldest, OffsetNewest:
+	default:
+		errs = append(errs, fmt.Errorf("initial offset %q must be %q or %q", c.InitialOffset, OffsetOldest, OffsetNewest))
+	}
+
+	switch c.RebalanceStrategy {
+	case RebalanceRange, RebalanceRoundRobin, RebalanceSticky, RebalanceCooperativeSticky:
+	default:
+		errs = append(errs, fmt.Errorf("rebalance strategy %q must be one of %s, %s, %s, %s", c.RebalanceStrategy,
+			RebalanceRange, RebalanceRoundRobin, RebalanceSticky, RebalanceCooperativeSticky))
+	}
+
+	switch c.IsolationLevel {
+	case IsolationReadUncommitted, IsolationReadCommitted:
+	default:
+		errs = append(errs, fmt.Errorf("isolation level %q must be %q or %q", c.IsolationLevel,
+			IsolationReadUncommitted, IsolationReadCommitted))
+	}
+
+	if c.SessionTimeout <= 0 {
+		errs = append(errs, errors.New("session timeout must be positive"))
+	}
+	if c.HeartbeatInterval <= 0 || c.HeartbeatInterval >= c.SessionTimeout {
+		errs = append(errs, errors.New("heartbeat interval must be positive and less than the session timeout"))
+	}
+	if c.MaxProcessingTime <= 0 {
+		errs = append(errs, errors.New("max processing time must be positive"))
+	}
+	if c.FetchMinBytes < 1 {
+		errs = append(errs, errors.New("fetch min bytes must be at least 1"))
+	}
+	if c.FetchDefaultBytes < 1 {
+		errs = append(errs, errors.New("fetch default bytes must be at least 1"))
+	}
+	if c.FetchMaxBytes < 0 || (c.FetchMaxBytes > 0 && c.FetchMaxBytes < c.FetchDefaultBytes) {
+		errs = append(errs, errors.New("fetch max bytes must be 0 (unlimited) or at least fetch default bytes"))
+	}
+
+	if c.DeadLetterTopic == "" {
+		errs = append(errs, errors.New("dead-letter topic is required"))
+	}
+	for _, topic := range c.Topics {
+		if topic == c.DeadLetterTopic {
+			errs = append(errs, fmt.Errorf("dead-letter topic %q must differ from consumed topics", topic))
+		}
+	}
+	if !slices.Contains(c.Topics, c.ProduceTopic) {
+		errs = append(errs, fmt.Errorf("produce topic %q must be one of the consumed topics", c.ProduceTopic))
+	}
+	if c.Retry.MaxAttempts < 1 {
+		errs = append(errs, errors.New("retry max attempts must be at least 1"))
+	}
+
+	errs = append(errs, c.SASL.validate(), c.TLS.validate())
+
+	return errors.Join(errs...)
+}
+
+func (c KafkaSASLConfig) validate() error {
+	switch c.Mechanism {
+	case "":
+		if c.Username != "" || c.Password != "" {
+			return errors.New("SASL credentials are set but KAFKA_SASL_MECHANISM is empty")
+		}
+		return nil
+	case SASLPlain, SASLScramSHA256, SASLScramSHA512:
+	default:
+		return fmt.Errorf("SASL mechanism %q must be one of %s, %s, %s", c.Mechanism,
+			SASLPlain, SASLScramSHA256, SASLScramSHA512)
+	}
+
+	if c.Username == "" || c.Password == "" {
+		return fmt.Errorf("SASL mechanism %s requires a username and a password", c.Mechanism)
+	}
+	return nil
+}
+
+func (c KafkaTLSConfig) validate() error {
+	if !c.Enabled {
+		if c.CAFile != "" || c.CertFile != "" || c.KeyFile != "" {
+			return errors.New("TLS files are set but KAFKA_TLS_ENABLED is false")
+		}
+		return nil
+	}
+
+	var errs []error
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		errs = append(errs, errors.New("TLS client certificate and key must be set together"))
+	}
+	files := []struct{ name, path string }{
+		{"CA bundle", c.CAFile},
+		{"client certificate", c.CertFile},
+		{"client key", c.KeyFile},
+	}
+	for _, file := range files {
+		if file.path == "" {
+			continue
+		}
+		if _, err := os.Stat(file.path); err != nil {
+			errs = append(errs, fmt.Errorf("TLS %s: %w", file.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Loader builds the effective configuration from, in increasing order of
+// precedence, built-in defaults, an optional YAML file, environment
+// variables and command-line flags. It keeps the parsed flags so the
+// configuration can be loaded again later.
+type Loader struct {
+	path  string
+	flags map[string]string
+}
+
+var flagNames = []struct {
+	name, usage string
+}{
+	{"log-level", "log level (debug, info, warn, error)"},
+	{"server-port", "HTTP server port"},
+	{"db-host", "PostgreSQL host"},
+	{"db-port", "PostgreSQL port"},
+	{"db-name", "PostgreSQL database name"},
+	{"db-user", "PostgreSQL user"},
+	{"kafka-brokers", "comma-separated Kafka brokers"},
+	{"kafka-topics", "comma-separated Kafka topics"},
+	{"kafka-group-id", "Kafka consumer group ID"},
+}
+
+// NewLoader parses command-line flags from args and returns the loader
+// together with the remaining positional arguments.
+func NewLoader(args []string) (*Loader, []string, error) {
+	fs := flag.NewFlagSet("order-service", flag.ContinueOnError)
+	path := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML configuration file")
+	values := make(map[string]*string, len(flagNames))
+	for _, f := range flagNames {
+		values[f.name] = fs.String(f.name, "", f.usage)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	loader := &Loader{path: *path, flags: make(map[string]string)}
+	fs.Visit(func(f *flag.Flag) {
+		if value, ok := values[f.Name]; ok {
+			loader.flags[f.Name] = *value
+		}
+	})
+
+	return loader, fs.Args(), nil
+}
+
+func (l *Loader) Path() string {
+	return l.path
+}
+
+func (l *Loader) Load() (*Config, error) {
+	cfg := Default()
+
+	if l.path != "" {
+		if err := loadFile(l.path, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := l.applyFlags(cfg); err != nil {
+		return nil, err
+	}
+
+	// SASL mechanisms are matched in upper case whatever their source.
+	cfg.Kafka.SASL.Mechanism = strings.ToUpper(strings.TrimSpace(cfg.Kafka.SASL.Mechanism))
+
+	// HTTP writes go to the first consumed topic unless configured
+	// otherwise.
+	if cfg.Kafka.ProduceTopic == "" && len(cfg.Kafka.Topics) > 0 {
+		cfg.Kafka.ProduceTopic = cfg.Kafka.Topics[0]
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func loadFile(path string, cfg *Config) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (l *Loader) applyFlags(cfg *Config) error {
+	p := &parser{source: "flag -"}
+	for name, value := range l.flags {
+		switch name {
+		case "log-level":
+			cfg.Log.Level = value
+		case "server-port":
+			p.integer(name, value, &cfg.Server.Port)
+		case "db-host":
+			cfg.Database.Host = value
+		case "db-port":
+			p.integer(name, value, &cfg.Database.Port)
+		case "db-name":
+			cfg.Database.DBName = value
+		case "db-user":
+			cfg.Database.User = value
+		case "kafka-brokers":
+			cfg.Kafka.Brokers = splitList(value)
+		case "kafka-topics":
+			cfg.Kafka.Topics = splitList(value)
+		case "kafka-group-id":
+			cfg.Kafka.GroupID = value
+		}
+	}
+	return p.err()
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
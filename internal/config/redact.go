@@ -0,0 +1,56 @@
+package config
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "******"
+
+// Redacted returns a copy of the configuration with secrets masked, safe
+// to print in logs.
+func (c Config) Redacted() Config {
+	out := c
+	out.Database.Password = mask(out.Database.Password)
+	out.Kafka.SASL.Username = mask(out.Kafka.SASL.Username)
+	out.Kafka.SASL.Password = mask(out.Kafka.SASL.Password)
+	out.Server.AdminToken = mask(out.Server.AdminToken)
+
+	if len(c.Database.Params) > 0 {
+		out.Database.Params = make(map[string]string, len(c.Database.Params))
+		for key, value := range c.Database.Params {
+			if strings.Contains(strings.ToLower(key), "password") {
+				value = mask(value)
+			}
+			out.Database.Params[key] = value
+		}
+	}
+
+	out.Kafka.Brokers = append([]string(nil), c.Kafka.Brokers...)
+	out.Kafka.Topics = append([]string(nil), c.Kafka.Topics...)
+	out.Server.CORSAllowedOrigins = append([]string(nil), c.Server.CORSAllowedOrigins...)
+	return out
+}
+
+// Map renders the redacted configuration as a generic map keyed by the
+// YAML field names, which is convenient for structured logging.
+func (c Config) Map() map[string]interface{} {
+	content, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return nil
+	}
+
+	var out map[string]interface{}
+	if err := yaml.Unmarshal(content, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+func mask(value string) string {
+	if value == "" {
+		return ""
+	}
+	return redacted
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// ReloadResult describes what a configuration reload changed. Applied
+// settings are already in effect; RestartRequired lists settings that
+// differ in the new configuration but only take effect after a restart.
+type ReloadResult struct {
+	Applied         []string `json:"applied"`
+	RestartRequired []string `json:"restart_required"`
+}
+
+// WithReloadable returns a copy of c with the settings that can be changed
+// at runtime taken from next.
+func (c *Config) WithReloadable(next *Config) *Config {
+	out := *c
+	out.Log.Level = next.Log.Level
+	out.Cache.MaxEntries = next.Cache.MaxEntries
+	out.Cache.MaxBytes = next.Cache.MaxBytes
+	out.Cache.TTL = next.Cache.TTL
+	out.Server.RateLimit = next.Server.RateLimit
+	out.Server.CORSAllowedOrigins = next.Server.CORSAllowedOrigins
+	out.Features = next.Features
+	out.Validation = next.Validation
+	return &out
+}
+
+// Changes lists the dotted YAML paths of the settings that differ between
+// a and b. Values are not included, so the result is safe to log.
+func Changes(a, b *Config) []string {
+	var changes []string
+	diff(reflect.ValueOf(*a), reflect.ValueOf(*b), "", &changes)
+	return changes
+}
+
+func diff(a, b reflect.Value, prefix string, changes *[]string) {
+	if a.Kind() != reflect.Struct {
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*changes = append(*changes, prefix)
+		}
+		return
+	}
+
+	for i := 0; i < a.NumField(); i++ {
+		name, _, _ := strings.Cut(a.Type().Field(i).Tag.Get("yaml"), ",")
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		diff(a.Field(i), b.Field(i), name, changes)
+	}
+}
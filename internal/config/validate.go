@@ -0,0 +1,236 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"order-service/internal/models"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+var sslModes = map[string]bool{
+	"disable":     true,
+	"allow":       true,
+	"prefer":      true,
+	"require":     true,
+	"verify-ca":   true,
+	"verify-full": true,
+}
+
+// Validate checks the whole configuration and reports every problem at
+// once so the service fails fast on startup.
+func (c *Config) Validate() error {
+	var errs []error
+
+	if _, err := logrus.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("invalid log config: %w", err))
+	}
+	if err := c.Database.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid database config: %w", err))
+	}
+	if err := c.Kafka.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid kafka config: %w", err))
+	}
+	if err := c.Server.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid server config: %w", err))
+	}
+	if err := c.Cache.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid cache config: %w", err))
+	}
+	if err := c.Health.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid health config: %w", err))
+	}
+	if err := c.Outbox.Validate(c.Kafka); err != nil {
+		errs = append(errs, fmt.Errorf("invalid outbox config: %w", err))
+	}
+	if err := c.Tracing.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid tracing config: %w", err))
+	}
+	if err := models.CheckSeverities(c.Validation.Rules); err != nil {
+		errs = append(errs, fmt.Errorf("invalid validation config: %w", err))
+	}
+	if err := c.Decoding.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid decoding config: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c DatabaseConfig) Validate() error {
+	var errs []error
+
+	required := []struct{ name, value string }{
+		{"database.host", c.Host},
+		{"database.user", c.User},
+		{"database.password", c.Password},
+		{"database.dbname", c.DBName},
+	}
+	for _, field := range required {
+		if strings.TrimSpace(field.value) == "" {
+			errs = append(errs, fmt.Errorf("%s is required", field.name))
+		}
+	}
+	if err := validatePort("database.port", c.Port); err != nil {
+		errs = append(errs, err)
+	}
+	if !sslModes[c.SSLMode] {
+		errs = append(errs, fmt.Errorf("unsupported database.sslmode %q", c.SSLMode))
+	}
+
+	nonNegative := []struct {
+		name  string
+		value int64
+	}{
+		{"database.pool.max_open_conns", int64(c.Pool.MaxOpenConns)},
+		{"database.pool.max_idle_conns", int64(c.Pool.MaxIdleConns)},
+		{"database.pool.conn_max_lifetime", int64(c.Pool.ConnMaxLifetime)},
+		{"database.pool.conn_max_idle_time", int64(c.Pool.ConnMaxIdleTime)},
+		{"database.connect_timeout", int64(c.ConnectTimeout)},
+		{"database.statement_timeout", int64(c.StatementTimeout)},
+		{"database.timeouts.save", int64(c.Timeouts.Save)},
+		{"database.timeouts.get", int64(c.Timeouts.Get)},
+		{"database.timeouts.load_batch", int64(c.Timeouts.Load)},
+		{"database.timeouts.search", int64(c.Timeouts.Search)},
+		{"database.connect.initial_backoff", int64(c.Connect.InitialBackoff)},
+		{"database.connect.max_backoff", int64(c.Connect.MaxBackoff)},
+	}
+	for _, field := range nonNegative {
+		if field.value < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", field.name))
+		}
+	}
+	if c.Connect.MaxAttempts < 1 {
+		errs = append(errs, errors.New("database.connect.max_attempts must be at least 1"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c ServerConfig) Validate() error {
+	var errs []error
+	if err := validatePort("server.port", c.Port); err != nil {
+		errs = append(errs, err)
+	}
+	for _, origin := range c.CORSAllowedOrigins {
+		if strings.TrimSpace(origin) == "" {
+			errs = append(errs, errors.New("server.cors_allowed_origins must not contain empty origins"))
+			break
+		}
+	}
+	if c.RateLimit.RequestsPerSecond < 0 {
+		errs = append(errs, errors.New("server.rate_limit.requests_per_second must not be negative"))
+	}
+	if c.RateLimit.RequestsPerSecond > 0 && c.RateLimit.Burst < 1 {
+		errs = append(errs, errors.New("server.rate_limit.burst must be at least 1 when rate limiting is enabled"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c CacheConfig) Validate() error {
+	var errs []error
+	if c.MaxEntries < 0 {
+		errs = append(errs, errors.New("cache.max_entries must not be negative"))
+	}
+	if c.MaxBytes < 0 {
+		errs = append(errs, errors.New("cache.max_bytes must not be negative"))
+	}
+	if c.TTL < 0 {
+		errs = append(errs, errors.New("cache.ttl must not be negative"))
+	}
+	if c.JanitorInterval <= 0 {
+		errs = append(errs, errors.New("cache.janitor_interval must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c HealthConfig) Validate() error {
+	var errs []error
+	if c.CheckTimeout <= 0 {
+		errs = append(errs, errors.New("health.check_timeout must be positive"))
+	}
+	if c.WarmUpRetryInterval <= 0 {
+		errs = append(errs, errors.New("health.warmup_retry_interval must be positive"))
+	}
+	if c.ConsumerStallThreshold < 0 {
+		errs = append(errs, errors.New("health.consumer_stall_threshold must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c OutboxConfig) Validate(kafka KafkaConfig) error {
+	var errs []error
+	if c.Topic == "" {
+		errs = append(errs, errors.New("outbox.topic is required"))
+	}
+	// Events published to a consumed topic would be read back as orders.
+	if slices.Contains(kafka.Topics, c.Topic) || c.Topic == kafka.DeadLetterTopic {
+		errs = append(errs, fmt.Errorf("outbox.topic %q must differ from consumed and dead-letter topics", c.Topic))
+	}
+	if c.BatchSize < 1 {
+		errs = append(errs, errors.New("outbox.batch_size must be at least 1"))
+	}
+	if c.PollInterval <= 0 {
+		errs = append(errs, errors.New("outbox.poll_interval must be positive"))
+	}
+	if c.InitialBackoff <= 0 {
+		errs = append(errs, errors.New("outbox.initial_backoff must be positive"))
+	}
+	if c.MaxBackoff < c.InitialBackoff {
+		errs = append(errs, errors.New("outbox.max_backoff must not be less than outbox.initial_backoff"))
+	}
+	if c.Retention < 0 {
+		errs = append(errs, errors.New("outbox.retention must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c TracingConfig) Validate() error {
+	var errs []error
+	switch c.Exporter {
+	case TracingExporterNone, TracingExporterStdout:
+	case TracingExporterOTLP:
+		if c.Endpoint == "" {
+			errs = append(errs, errors.New("tracing.endpoint is required for the otlp exporter"))
+		}
+	case TracingExporterFile:
+		if c.File == "" {
+			errs = append(errs, errors.New("tracing.file is required for the file exporter"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter %q must be one of %s, %s, %s, %s", c.Exporter,
+			TracingExporterNone, TracingExporterOTLP, TracingExporterStdout, TracingExporterFile))
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracing.sample_ratio %v must be between 0 and 1", c.SampleRatio))
+	}
+	if c.ServiceName == "" {
+		errs = append(errs, errors.New("tracing.service_name is required"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c DecodingConfig) Validate() error {
+	var errs []error
+	switch models.Severity(c.UnknownFields) {
+	case models.SeverityReject, models.SeverityWarn:
+	default:
+		errs = append(errs, fmt.Errorf("decoding.unknown_fields %q must be %q or %q",
+			c.UnknownFields, models.SeverityReject, models.SeverityWarn))
+	}
+	if c.MaxMessageBytes < 0 {
+		errs = append(errs, errors.New("decoding.max_message_bytes must not be negative"))
+	}
+	if c.MaxItems < 0 {
+		errs = append(errs, errors.New("decoding.max_items must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+func validatePort(name string, port int) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("%s %d is out of range 1-65535", name, port)
+	}
+	return nil
+}
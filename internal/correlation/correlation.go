@@ -0,0 +1,115 @@
+// Package correlation carries request IDs and W3C trace context through
+// HTTP requests, Kafka messages and context.Context, and adds them to log
+// entries.
+package correlation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	HeaderRequestID   = "X-Request-ID"
+	HeaderTraceparent = "traceparent"
+
+	// Kafka header keys are conventionally lower case.
+	KafkaHeaderRequestID = "x-request-id"
+)
+
+// maxRequestIDLength bounds caller-supplied IDs so they cannot bloat logs.
+const maxRequestIDLength = 128
+
+// IDs identify the unit of work a context belongs to.
+type IDs struct {
+	RequestID string
+	Trace     TraceContext
+	OrderUID  string
+}
+
+type idsKey struct{}
+
+func NewContext(ctx context.Context, ids IDs) context.Context {
+	return context.WithValue(ctx, idsKey{}, ids)
+}
+
+func FromContext(ctx context.Context) IDs {
+	ids, _ := ctx.Value(idsKey{}).(IDs)
+	return ids
+}
+
+// WithOrderUID records the order being worked on so that log entries of
+// its whole path, from the producer to API reads, can be found by UID.
+func WithOrderUID(ctx context.Context, orderUID string) context.Context {
+	ids := FromContext(ctx)
+	ids.OrderUID = orderUID
+	return NewContext(ctx, ids)
+}
+
+// Incoming builds the IDs for a request or message from the values the
+// caller sent. Missing or malformed values are replaced with new ones; a
+// valid trace context is continued with a new span.
+func Incoming(requestID, traceparent string) IDs {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		requestID = NewRequestID()
+	}
+
+	trace, ok := ParseTraceparent(traceparent)
+	if ok {
+		trace = trace.Child()
+	} else {
+		trace = NewTrace()
+	}
+
+	return IDs{RequestID: requestID, Trace: trace}
+}
+
+// Fields returns the IDs as logrus fields, omitting empty ones.
+func (ids IDs) Fields() logrus.Fields {
+	fields := logrus.Fields{}
+	if ids.RequestID != "" {
+		fields["request_id"] = ids.RequestID
+	}
+	if ids.Trace.Valid() {
+		fields["trace_id"] = ids.Trace.TraceID
+		fields["span_id"] = ids.Trace.SpanID
+	}
+	if ids.OrderUID != "" {
+		fields["order_uid"] = ids.OrderUID
+	}
+	return fields
+}
+
+func NewRequestID() string {
+	return randomHex(16)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// Hook adds the IDs stored in an entry's context to the entry. Log through
+// logger.WithContext(ctx) for the fields to appear.
+type Hook struct{}
+
+func (Hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (Hook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	for key, value := range FromContext(entry.Context).Fields() {
+		if _, exists := entry.Data[key]; !exists {
+			entry.Data[key] = value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package correlation
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	traceIDLength = 32
+	spanIDLength  = 16
+	zeroTraceID   = "00000000000000000000000000000000"
+	zeroSpanID    = "0000000000000000"
+)
+
+// TraceContext is the part of a W3C traceparent header this service uses.
+type TraceContext struct {
+	TraceID string
+	SpanID  string
+	Flags   string
+}
+
+// ParseTraceparent parses a version 00 traceparent header. Unknown future
+// versions are accepted as long as the fields this service needs are
+// well-formed.
+func ParseTraceparent(header string) (TraceContext, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return TraceContext{}, false
+	}
+
+	trace := TraceContext{TraceID: parts[1], SpanID: parts[2], Flags: parts[3]}
+	if !isLowerHex(parts[0]) || !isLowerHex(trace.Flags) || len(trace.Flags) != 2 || !trace.Valid() {
+		return TraceContext{}, false
+	}
+	return trace, true
+}
+
+func NewTrace() TraceContext {
+	return TraceContext{TraceID: randomHex(traceIDLength / 2), SpanID: randomHex(spanIDLength / 2), Flags: "01"}
+}
+
+// Child returns a new span in the same trace.
+func (t TraceContext) Child() TraceContext {
+	return TraceContext{TraceID: t.TraceID, SpanID: randomHex(spanIDLength / 2), Flags: t.Flags}
+}
+
+func (t TraceContext) Valid() bool {
+	return len(t.TraceID) == traceIDLength && isLowerHex(t.TraceID) && t.TraceID != zeroTraceID &&
+		len(t.SpanID) == spanIDLength && isLowerHex(t.SpanID) && t.SpanID != zeroSpanID
+}
+
+// String formats the trace context as a traceparent header value.
+func (t TraceContext) String() string {
+	if !t.Valid() {
+		return ""
+	}
+	flags := t.Flags
+	if flags == "" {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", t.TraceID, t.SpanID, flags)
+}
+
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return s != ""
+}
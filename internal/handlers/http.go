@@ -1,49 +1,114 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"order-service/internal/cache"
+	"order-service/internal/config"
+	"order-service/internal/correlation"
+	"order-service/internal/health"
+	"order-service/internal/metrics"
 	"order-service/internal/models"
+	"sync/atomic"
 
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
 )
 
 type HTTPHandler struct {
 	cache      OrderCache
 	repository OrderRepository
+	health     HealthChecker
 	log        *logrus.Logger
+
+	settings   atomic.Pointer[Settings]
+	limiter    *rate.Limiter
+	reloader   Reloader
+	adminToken string
+	publisher  OrderPublisher
+	validator  *models.OrderValidator
+	decoder    *models.Decoder
 }
 
 type OrderCache interface {
-	Get(orderUID string) (*models.Order, bool)
-	Set(orderUID string, order *models.Order)
+	Get(ctx context.Context, orderUID string) (*models.Order, bool)
+	Set(ctx context.Context, orderUID string, order *models.Order)
 	Size() int
+	Stats() cache.Stats
 }
 
 type OrderRepository interface {
-	GetOrder(orderUID string) (*models.Order, error)
+	GetOrder(ctx context.Context, orderUID string) (*models.Order, error)
+	SearchOrders(ctx context.Context, filter models.OrderFilter) (*models.OrderPage, error)
+	GetStatusHistory(ctx context.Context, orderUID string) (*models.StatusHistory, error)
+}
+
+type HealthChecker interface {
+	Run(ctx context.Context) health.Report
 }
 
-func NewHTTPHandler(cache OrderCache, repo OrderRepository, logger *logrus.Logger) *HTTPHandler {
-	return &HTTPHandler{
+func NewHTTPHandler(cache OrderCache, repo OrderRepository, checker HealthChecker, logger *logrus.Logger) *HTTPHandler {
+	h := &HTTPHandler{
 		cache:      cache,
 		repository: repo,
+		health:     checker,
 		log:        logger,
+		limiter:    rate.NewLimiter(rate.Inf, 0),
+		validator:  models.NewOrderValidator(),
+		decoder:    models.NewDecoder(models.DecodeOptions{UnknownFields: models.SeverityWarn}),
 	}
+	h.settings.Store(defaultSettings())
+	return h
 }
 
 func (h *HTTPHandler) SetupRoutes() *mux.Router {
 	router := mux.NewRouter()
 
+	search := func(f config.FeaturesConfig) bool { return f.Search }
+	cacheStats := func(f config.FeaturesConfig) bool { return f.CacheStats }
+	webUI := func(f config.FeaturesConfig) bool { return f.WebUI }
+
 	api := router.PathPrefix("/api/v1").Subrouter()
+	api.Handle("/orders", h.requireFeature(search, http.HandlerFunc(h.ListOrders))).Methods("GET")
 	api.HandleFunc("/order/{order_uid}", h.GetOrder).Methods("GET")
+	api.HandleFunc("/order/{order_uid}/history", h.GetOrderHistory).Methods("GET")
 	api.HandleFunc("/health", h.HealthCheck).Methods("GET")
-	api.HandleFunc("/cache/stats", h.CacheStats).Methods("GET")
+	api.Handle("/cache/stats", h.requireFeature(cacheStats, http.HandlerFunc(h.CacheStats))).Methods("GET")
+	if h.publisher != nil {
+		api.HandleFunc("/orders", h.CreateOrder).Methods("POST")
+		api.HandleFunc("/order/{order_uid}", h.UpdateOrder).Methods("PUT")
+		api.HandleFunc("/order/{order_uid}/cancel", h.CancelOrder).Methods("POST")
+		if h.adminToken != "" {
+			api.HandleFunc("/order/{order_uid}", h.DeleteOrder).Methods("DELETE")
+		}
+	}
+	api.Use(h.rateLimitMiddleware)
 
-	router.HandleFunc("/", h.ServeIndex).Methods("GET")
-	router.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("./web/static/"))))
+	if h.reloader != nil && h.adminToken != "" {
+		router.HandleFunc("/admin/reload", h.ReloadConfig).Methods("POST")
+	}
 
+	router.HandleFunc("/livez", h.Livez).Methods("GET")
+	router.HandleFunc("/readyz", h.Readyz).Methods("GET")
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+	router.Handle("/", h.requireFeature(webUI, http.HandlerFunc(h.ServeIndex))).Methods("GET")
+	router.PathPrefix("/static/").Handler(h.requireFeature(webUI,
+		http.StripPrefix("/static/", http.FileServer(http.Dir("./web/static/")))))
+
+	// Preflights of the write routes match no route by method, and mux runs
+	// middleware only on a match; this catch-all lets corsMiddleware answer.
+	router.Methods(http.MethodOptions).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	router.NotFoundHandler = h.correlationMiddleware(http.HandlerFunc(h.notFound))
+	router.MethodNotAllowedHandler = h.correlationMiddleware(http.HandlerFunc(h.methodNotAllowed))
+
+	router.Use(h.correlationMiddleware)
+	router.Use(h.tracingMiddleware)
+	router.Use(h.metricsMiddleware)
 	router.Use(h.loggingMiddleware)
 	router.Use(h.corsMiddleware)
 
@@ -55,49 +120,57 @@ func (h *HTTPHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
 	orderUID := vars["order_uid"]
 
 	if orderUID == "" {
-		h.writeErrorResponse(w, http.StatusBadRequest, "order_uid is required")
+		h.writeProblem(w, r, models.NewFieldError("order_uid", models.ErrInvalidOrderUID))
 		return
 	}
 
-	h.log.Infof("Fetching order: %s", orderUID)
+	ctx := correlation.WithOrderUID(r.Context(), orderUID)
+	r = r.WithContext(ctx)
+	log := h.log.WithContext(ctx)
+	log.Infof("Fetching order: %s", orderUID)
 
-	if order, found := h.cache.Get(orderUID); found {
-		h.log.Debugf("Order %s found in cache", orderUID)
+	if order, found := h.cache.Get(ctx, orderUID); found {
+		log.Debugf("Order %s found in cache", orderUID)
 		h.writeJSONResponse(w, http.StatusOK, order)
 		return
 	}
 
-	h.log.Debugf("Order %s not in cache, fetching from database", orderUID)
-	order, err := h.repository.GetOrder(orderUID)
+	log.Debugf("Order %s not in cache, fetching from database", orderUID)
+	order, err := h.repository.GetOrder(ctx, orderUID)
 	if err != nil {
-		if err == models.ErrOrderNotFound {
-			h.writeErrorResponse(w, http.StatusNotFound, "order not found")
-			return
-		}
-		h.log.Errorf("Failed to get order from database: %v", err)
-		h.writeErrorResponse(w, http.StatusInternalServerError, "internal server error")
+		h.writeProblem(w, r, err)
 		return
 	}
 
-	h.cache.Set(orderUID, order)
-	h.log.Debugf("Order %s added to cache", orderUID)
+	h.cache.Set(ctx, orderUID, order)
+	log.Debugf("Order %s added to cache", orderUID)
 
 	h.writeJSONResponse(w, http.StatusOK, order)
 }
 
 func (h *HTTPHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	h.Readyz(w, r)
+}
+
+func (h *HTTPHandler) Livez(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{
-		"status":     "ok",
-		"cache_size": h.cache.Size(),
+		"status": health.StatusUp,
 	}
 	h.writeJSONResponse(w, http.StatusOK, response)
 }
 
-func (h *HTTPHandler) CacheStats(w http.ResponseWriter, r *http.Request) {
-	response := map[string]interface{}{
-		"cache_size": h.cache.Size(),
+func (h *HTTPHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := h.health.Run(r.Context())
+
+	statusCode := http.StatusOK
+	if report.Status != health.StatusUp {
+		statusCode = http.StatusServiceUnavailable
 	}
-	h.writeJSONResponse(w, http.StatusOK, response)
+	h.writeJSONResponse(w, statusCode, report)
+}
+
+func (h *HTTPHandler) CacheStats(w http.ResponseWriter, r *http.Request) {
+	h.writeJSONResponse(w, http.StatusOK, h.cache.Stats())
 }
 
 func (h *HTTPHandler) ServeIndex(w http.ResponseWriter, r *http.Request) {
@@ -113,25 +186,24 @@ func (h *HTTPHandler) writeJSONResponse(w http.ResponseWriter, statusCode int, d
 	}
 }
 
-func (h *HTTPHandler) writeErrorResponse(w http.ResponseWriter, statusCode int, message string) {
-	response := map[string]string{
-		"error": message,
-	}
-	h.writeJSONResponse(w, statusCode, response)
-}
-
 func (h *HTTPHandler) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		h.log.Infof("%s %s %s", r.Method, r.RequestURI, r.RemoteAddr)
+		h.log.WithContext(r.Context()).Infof("%s %s %s", r.Method, r.RequestURI, r.RemoteAddr)
 		next.ServeHTTP(w, r)
 	})
 }
 
 func (h *HTTPHandler) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if origin := h.allowedOrigin(r.Header.Get("Origin")); origin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if origin != "*" {
+				w.Header().Add("Vary", "Origin")
+			}
+		}
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Requested-By, X-Request-ID, traceparent")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, traceparent, Location")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"order-service/internal/correlation"
+	"order-service/internal/models"
+)
+
+const problemContentType = "application/problem+json"
+
+var (
+	errRouteNotFound    = errors.New("route not found")
+	errMethodNotAllowed = errors.New("method not allowed")
+	errEmptyBody        = errors.New("request body is empty")
+	errBodyTooLarge     = errors.New("request body is too large")
+	errOrderUIDMismatch = errors.New("order_uid in body does not match the URL")
+	errPublishFailed    = errors.New("failed to hand the request over to Kafka")
+	errRateLimited      = errors.New("rate limit exceeded")
+	errUnauthorized     = errors.New("missing or invalid admin token")
+	errFeatureDisabled  = errors.New("feature disabled")
+	errInvalidConfig    = errors.New("invalid configuration")
+)
+
+// Problem is an RFC 7807 problem details document extended with a stable
+// machine-readable code, field-level violations and the request ID.
+type Problem struct {
+	Type      string         `json:"type"`
+	Title     string         `json:"title"`
+	Status    int            `json:"status"`
+	Detail    string         `json:"detail,omitempty"`
+	Instance  string         `json:"instance,omitempty"`
+	Code      string         `json:"code"`
+	RequestID string         `json:"request_id,omitempty"`
+	Errors    []ProblemField `json:"errors,omitempty"`
+}
+
+type ProblemField struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type problemKind struct {
+	err    error
+	status int
+	code   string
+	title  string
+}
+
+// problemKinds maps domain errors to HTTP problems. It is the only place
+// that decides status codes and error codes; entries are matched in order
+// with errors.Is, so more specific errors come first.
+var problemKinds = []problemKind{
+	{models.ErrOrderNotFound, http.StatusNotFound, "order_not_found", "Order not found"},
+	{models.ErrInvalidOrderUID, http.StatusBadRequest, "invalid_order_uid", "Invalid order UID"},
+	{models.ErrInvalidTrackNumber, http.StatusBadRequest, "invalid_track_number", "Invalid track number"},
+	{models.ErrEmptyItems, http.StatusBadRequest, "empty_items", "Order has no items"},
+	{models.ErrUnknownField, http.StatusBadRequest, "unknown_field", "Unknown field"},
+	{models.ErrTypeMismatch, http.StatusBadRequest, "type_mismatch", "Field has the wrong type"},
+	{models.ErrInvalidJSON, http.StatusBadRequest, "invalid_json", "Malformed JSON"},
+	{models.ErrTooManyItems, http.StatusBadRequest, "too_many_items", "Too many array elements"},
+	{models.ErrPayloadTooLarge, http.StatusRequestEntityTooLarge, "payload_too_large", "Payload too large"},
+	{models.ErrInvalidFilter, http.StatusBadRequest, "invalid_filter", "Invalid search filter"},
+	{models.ErrInvalidCursor, http.StatusBadRequest, "invalid_cursor", "Invalid pagination cursor"},
+	{models.ErrInvalidStatus, http.StatusBadRequest, "invalid_status", "Unknown order status"},
+	{models.ErrMissingChangedBy, http.StatusBadRequest, "missing_changed_by", "Missing author of the change"},
+	{models.ErrNegativeAmount, http.StatusBadRequest, "negative_amount", "Negative amount"},
+	{models.ErrTotalMismatch, http.StatusBadRequest, "total_mismatch", "Totals do not add up"},
+	{models.ErrTrackNumberMismatch, http.StatusBadRequest, "track_number_mismatch", "Item track number mismatch"},
+	{models.ErrInvalidCurrency, http.StatusBadRequest, "invalid_currency", "Invalid currency"},
+	{models.ErrInvalidEmail, http.StatusBadRequest, "invalid_email", "Invalid email address"},
+	{models.ErrInvalidPhone, http.StatusBadRequest, "invalid_phone", "Invalid phone number"},
+	{models.ErrMissingDateCreated, http.StatusBadRequest, "missing_date_created", "Missing creation date"},
+	{models.ErrInvalidTransition, http.StatusConflict, "invalid_status_transition", "Status transition not allowed"},
+	{models.ErrStaleVersion, http.StatusConflict, "stale_version", "Stale order version"},
+	{errEmptyBody, http.StatusBadRequest, "empty_body", "Empty request body"},
+	{errBodyTooLarge, http.StatusRequestEntityTooLarge, "body_too_large", "Request body too large"},
+	{errOrderUIDMismatch, http.StatusBadRequest, "order_uid_mismatch", "Order UID mismatch"},
+	{errUnauthorized, http.StatusUnauthorized, "unauthorized", "Unauthorized"},
+	{errFeatureDisabled, http.StatusNotFound, "feature_disabled", "Feature disabled"},
+	{errRouteNotFound, http.StatusNotFound, "route_not_found", "Route not found"},
+	{errMethodNotAllowed, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed"},
+	{errRateLimited, http.StatusTooManyRequests, "rate_limited", "Too many requests"},
+	{errInvalidConfig, http.StatusUnprocessableEntity, "invalid_config", "Invalid configuration"},
+	{context.DeadlineExceeded, http.StatusGatewayTimeout, "timeout", "Request timed out"},
+	{errPublishFailed, http.StatusServiceUnavailable, "publish_failed", "Request not accepted"},
+	{models.ErrTransient, http.StatusServiceUnavailable, "temporarily_unavailable", "Service temporarily unavailable"},
+}
+
+var internalProblem = problemKind{nil, http.StatusInternalServerError, "internal_error", "Internal server error"}
+
+func problemKindOf(err error) problemKind {
+	for _, kind := range problemKinds {
+		if errors.Is(err, kind.err) {
+			return kind
+		}
+	}
+	return internalProblem
+}
+
+// newProblem builds the problem document for err. Validation failures list
+// every offending field; details of server-side errors are not exposed.
+func newProblem(r *http.Request, err error) Problem {
+	kind := problemKindOf(err)
+	problem := Problem{
+		Status:    kind.status,
+		Code:      kind.code,
+		Title:     kind.title,
+		Instance:  r.URL.Path,
+		RequestID: correlation.FromContext(r.Context()).RequestID,
+	}
+
+	if fields := models.FieldErrors(err); len(fields) > 0 {
+		problem.Code = "validation_failed"
+		problem.Title = "Validation failed"
+		for _, field := range fields {
+			problem.Errors = append(problem.Errors, problemField(field))
+		}
+	}
+
+	if kind.status < http.StatusInternalServerError {
+		problem.Detail = err.Error()
+	}
+	problem.Type = "/problems/" + problem.Code
+	return problem
+}
+
+func problemField(field *models.FieldError) ProblemField {
+	return ProblemField{
+		Field:   field.Field,
+		Code:    problemKindOf(field.Err).code,
+		Message: field.Err.Error(),
+	}
+}
+
+func (h *HTTPHandler) writeProblem(w http.ResponseWriter, r *http.Request, err error) {
+	problem := newProblem(r, err)
+	if problem.Status >= http.StatusInternalServerError {
+		h.log.WithContext(r.Context()).Errorf("%s %s failed: %v", r.Method, r.URL.Path, err)
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		h.log.WithContext(r.Context()).Errorf("Failed to encode problem response: %v", err)
+	}
+}
+
+func (h *HTTPHandler) notFound(w http.ResponseWriter, r *http.Request) {
+	h.writeProblem(w, r, errRouteNotFound)
+}
+
+func (h *HTTPHandler) methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	h.writeProblem(w, r, errMethodNotAllowed)
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+
+	"order-service/internal/correlation"
+)
+
+// correlationMiddleware reuses the caller's X-Request-ID and traceparent or
+// generates them, stores them in the request context and echoes them in the
+// response. The traceparent sent back names the span of this request.
+func (h *HTTPHandler) correlationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids := correlation.Incoming(r.Header.Get(correlation.HeaderRequestID), r.Header.Get(correlation.HeaderTraceparent))
+
+		w.Header().Set(correlation.HeaderRequestID, ids.RequestID)
+		w.Header().Set(correlation.HeaderTraceparent, ids.Trace.String())
+		ctx := correlation.NewContext(r.Context(), ids)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"order-service/internal/models"
+	"strconv"
+	"time"
+)
+
+func (h *HTTPHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseOrderFilter(r.URL.Query())
+	if err != nil {
+		h.writeProblem(w, r, err)
+		return
+	}
+
+	page, err := h.repository.SearchOrders(r.Context(), filter)
+	if err != nil {
+		h.writeProblem(w, r, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, page)
+}
+
+func parseOrderFilter(query url.Values) (models.OrderFilter, error) {
+	filter := models.OrderFilter{
+		TrackNumber:     query.Get("track_number"),
+		CustomerID:      query.Get("customer_id"),
+		DeliveryService: query.Get("delivery_service"),
+		Transaction:     query.Get("transaction"),
+		Phone:           query.Get("phone"),
+		Email:           query.Get("email"),
+		Sort:            query.Get("sort"),
+	}
+
+	var err error
+	if filter.ChrtID, err = parseOptionalInt(query, "chrt_id"); err != nil {
+		return filter, err
+	}
+	if filter.NmID, err = parseOptionalInt(query, "nm_id"); err != nil {
+		return filter, err
+	}
+	if filter.DateFrom, err = parseOptionalTime(query, "date_from"); err != nil {
+		return filter, err
+	}
+	if filter.DateTo, err = parseOptionalTime(query, "date_to"); err != nil {
+		return filter, err
+	}
+
+	if value := query.Get("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit < 1 {
+			return filter, models.NewFieldError("limit",
+				fmt.Errorf("%w: must be a positive integer", models.ErrInvalidFilter))
+		}
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		if filter.Cursor, err = models.DecodeOrderCursor(value); err != nil {
+			return filter, models.NewFieldError("cursor", err)
+		}
+	}
+
+	return filter, nil
+}
+
+func parseOptionalInt(query url.Values, key string) (*int, error) {
+	value := query.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, models.NewFieldError(key, fmt.Errorf("%w: must be an integer", models.ErrInvalidFilter))
+	}
+	return &parsed, nil
+}
+
+func parseOptionalTime(query url.Values, key string) (time.Time, error) {
+	value := query.Get(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	if parsed, err := time.Parse("2006-01-02", value); err == nil {
+		return parsed, nil
+	}
+	return time.Time{}, models.NewFieldError(key,
+		fmt.Errorf("%w: must be an RFC 3339 timestamp or a YYYY-MM-DD date", models.ErrInvalidFilter))
+}
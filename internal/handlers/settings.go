@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"order-service/internal/config"
+
+	"golang.org/x/time/rate"
+)
+
+// Settings holds the HTTP behaviour that can be changed while the server is
+// running.
+type Settings struct {
+	AllowedOrigins []string
+	RateLimit      float64
+	RateBurst      int
+	Features       config.FeaturesConfig
+}
+
+// Reloader re-reads the service configuration and applies what it can.
+type Reloader interface {
+	Reload() (config.ReloadResult, error)
+}
+
+func defaultSettings() *Settings {
+	return &Settings{
+		AllowedOrigins: []string{"*"},
+		Features: config.FeaturesConfig{
+			WebUI:      true,
+			Search:     true,
+			CacheStats: true,
+		},
+	}
+}
+
+// SetSettings atomically replaces the runtime settings.
+func (h *HTTPHandler) SetSettings(settings Settings) {
+	if settings.RateLimit > 0 {
+		h.limiter.SetBurst(settings.RateBurst)
+		h.limiter.SetLimit(rate.Limit(settings.RateLimit))
+	} else {
+		h.limiter.SetLimit(rate.Inf)
+	}
+	h.settings.Store(&settings)
+}
+
+// SetReloader enables POST /admin/reload, authorized by a bearer token. It
+// must be called before SetupRoutes.
+func (h *HTTPHandler) SetReloader(reloader Reloader, token string) {
+	h.reloader = reloader
+	h.adminToken = token
+}
+
+func (h *HTTPHandler) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	if !h.adminAuthorized(r) {
+		h.writeProblem(w, r, errUnauthorized)
+		return
+	}
+
+	result, err := h.reloader.Reload()
+	if err != nil {
+		h.log.WithContext(r.Context()).Errorf("Configuration reload failed: %v", err)
+		h.writeProblem(w, r, fmt.Errorf("%w: %w", errInvalidConfig, err))
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, result)
+}
+
+// adminAuthorized reports whether r carries the admin bearer token. It is
+// false for every request while no token is configured.
+func (h *HTTPHandler) adminAuthorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return h.adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) == 1
+}
+
+func (h *HTTPHandler) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !h.limiter.Allow() {
+			w.Header().Set("Retry-After", "1")
+			h.writeProblem(w, r, errRateLimited)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireFeature responds with 404 while the feature selected by enabled is
+// switched off.
+func (h *HTTPHandler) requireFeature(enabled func(config.FeaturesConfig) bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !enabled(h.settings.Load().Features) {
+			h.writeProblem(w, r, errFeatureDisabled)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *HTTPHandler) allowedOrigin(origin string) string {
+	for _, allowed := range h.settings.Load().AllowedOrigins {
+		if allowed == "*" {
+			return "*"
+		}
+		if origin != "" && strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *HTTPHandler) GetOrderHistory(w http.ResponseWriter, r *http.Request) {
+	orderUID := mux.Vars(r)["order_uid"]
+
+	history, err := h.repository.GetStatusHistory(r.Context(), orderUID)
+	if err != nil {
+		h.writeProblem(w, r, err)
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, history)
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"order-service/internal/correlation"
+	"order-service/internal/tracing"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingMiddleware runs every matched route in a server span named after
+// its path template and returns the span in the traceparent header.
+func (h *HTTPHandler) tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r)
+		ctx, span := tracing.Continue(r.Context(), r.Header.Get(correlation.HeaderTraceparent), r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+			))
+
+		w.Header().Set(correlation.HeaderTraceparent, correlation.FromContext(ctx).Trace.String())
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(recorder.status))
+		var err error
+		if recorder.status >= http.StatusInternalServerError {
+			err = fmt.Errorf("%s", http.StatusText(recorder.status))
+		}
+		tracing.End(span, err)
+	})
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"order-service/internal/correlation"
+	"order-service/internal/kafka"
+	"order-service/internal/metrics"
+	"order-service/internal/models"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// maxWriteBodyBytes bounds request bodies when the decoder sets no limit.
+const maxWriteBodyBytes = 1 << 20
+
+// headerRequestedBy names the actor recorded in the status history for
+// cancellations made over HTTP.
+const headerRequestedBy = "X-Requested-By"
+
+// OrderPublisher sends order writes to Kafka, where they are applied by the
+// same consumer that ingests the orders topic.
+type OrderPublisher interface {
+	PublishOrder(ctx context.Context, order *models.Order) (kafka.Receipt, error)
+	PublishCancel(ctx context.Context, cancel *models.OrderCancellation) (kafka.Receipt, error)
+	PublishDelete(ctx context.Context, deletion *models.OrderDeletion) (kafka.Receipt, error)
+}
+
+type writeAccepted struct {
+	Status   string `json:"status"`
+	OrderUID string `json:"order_uid"`
+	kafka.Receipt
+	Warnings []ProblemField `json:"warnings,omitempty"`
+}
+
+// SetPublisher enables the order write endpoints. It must be called before
+// SetupRoutes.
+func (h *HTTPHandler) SetPublisher(publisher OrderPublisher) {
+	h.publisher = publisher
+}
+
+// SetValidator replaces the default order validator, so HTTP writes and the
+// Kafka consumer share rule severities.
+func (h *HTTPHandler) SetValidator(validator *models.OrderValidator) {
+	h.validator = validator
+}
+
+// SetDecoder replaces the default decoder, so HTTP writes and the Kafka
+// consumer apply the same strictness and limits.
+func (h *HTTPHandler) SetDecoder(decoder *models.Decoder) {
+	h.decoder = decoder
+}
+
+func (h *HTTPHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	var order models.Order
+	decodeWarnings, err := h.decodeBody(w, r, &order)
+	if err != nil {
+		h.writeProblem(w, r, err)
+		return
+	}
+
+	h.publishOrder(w, r, &order, decodeWarnings)
+}
+
+func (h *HTTPHandler) UpdateOrder(w http.ResponseWriter, r *http.Request) {
+	orderUID := mux.Vars(r)["order_uid"]
+
+	var order models.Order
+	decodeWarnings, err := h.decodeBody(w, r, &order)
+	if err != nil {
+		h.writeProblem(w, r, err)
+		return
+	}
+
+	if order.OrderUID == "" {
+		order.OrderUID = orderUID
+	}
+	if order.OrderUID != orderUID {
+		h.writeProblem(w, r, models.NewFieldError("order_uid", errOrderUIDMismatch))
+		return
+	}
+
+	h.publishOrder(w, r, &order, decodeWarnings)
+}
+
+func (h *HTTPHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	cancel := models.OrderCancellation{
+		OrderUID:    mux.Vars(r)["order_uid"],
+		RequestedBy: r.Header.Get(headerRequestedBy),
+		RequestedAt: time.Now().UTC(),
+	}
+	if cancel.RequestedBy == "" {
+		cancel.RequestedBy = "http"
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	decodeWarnings, err := h.decodeBody(w, r, &body)
+	if err != nil && err != errEmptyBody {
+		h.writeProblem(w, r, err)
+		return
+	}
+	cancel.Reason = body.Reason
+
+	if err := cancel.Validate(); err != nil {
+		h.writeProblem(w, r, err)
+		return
+	}
+
+	receipt, err := h.publisher.PublishCancel(correlation.WithOrderUID(r.Context(), cancel.OrderUID), &cancel)
+	if err != nil {
+		h.writeProblem(w, r, fmt.Errorf("%w: cancellation of order %s: %w", errPublishFailed, cancel.OrderUID, err))
+		return
+	}
+
+	h.writeAccepted(w, cancel.OrderUID, receipt, h.decodeWarnings(r, decodeWarnings)...)
+}
+
+// DeleteOrder publishes the deletion of an order. It is authorized by the
+// admin token since the order and its history cannot be restored.
+func (h *HTTPHandler) DeleteOrder(w http.ResponseWriter, r *http.Request) {
+	if !h.adminAuthorized(r) {
+		h.writeProblem(w, r, errUnauthorized)
+		return
+	}
+
+	deletion := models.OrderDeletion{
+		OrderUID:    mux.Vars(r)["order_uid"],
+		RequestedBy: r.Header.Get(headerRequestedBy),
+		RequestedAt: time.Now().UTC(),
+	}
+	if deletion.RequestedBy == "" {
+		deletion.RequestedBy = "http"
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	decodeWarnings, err := h.decodeBody(w, r, &body)
+	if err != nil && err != errEmptyBody {
+		h.writeProblem(w, r, err)
+		return
+	}
+	deletion.Reason = body.Reason
+
+	if err := deletion.Validate(); err != nil {
+		h.writeProblem(w, r, err)
+		return
+	}
+
+	receipt, err := h.publisher.PublishDelete(correlation.WithOrderUID(r.Context(), deletion.OrderUID), &deletion)
+	if err != nil {
+		h.writeProblem(w, r, fmt.Errorf("%w: deletion of order %s: %w", errPublishFailed, deletion.OrderUID, err))
+		return
+	}
+
+	h.writeAccepted(w, deletion.OrderUID, receipt, h.decodeWarnings(r, decodeWarnings)...)
+}
+
+func (h *HTTPHandler) publishOrder(w http.ResponseWriter, r *http.Request, order *models.Order, decodeWarnings []*models.FieldError) {
+	ctx := correlation.WithOrderUID(r.Context(), order.OrderUID)
+	result := h.validator.Validate(order)
+	for _, violation := range result.Violations {
+		metrics.ValidationViolationsTotal.WithLabelValues(violation.Rule, string(violation.Severity), "http").Inc()
+	}
+	if err := result.Err(); err != nil {
+		h.writeProblem(w, r, err)
+		return
+	}
+
+	receipt, err := h.publisher.PublishOrder(ctx, order)
+	if err != nil {
+		h.writeProblem(w, r, fmt.Errorf("%w: order %s: %w", errPublishFailed, order.OrderUID, err))
+		return
+	}
+
+	// Warnings do not block the write; they are returned so the client can
+	// fix its data.
+	warnings := h.decodeWarnings(r.WithContext(ctx), decodeWarnings)
+	for _, warning := range result.Warnings() {
+		h.log.WithContext(ctx).WithField("rule", warning.Rule).Warnf("Order validation warning: %v", warning.FieldError)
+		warnings = append(warnings, problemField(warning.FieldError))
+	}
+	h.writeAccepted(w, order.OrderUID, receipt, warnings...)
+}
+
+func (h *HTTPHandler) writeAccepted(w http.ResponseWriter, orderUID string, receipt kafka.Receipt, warnings ...ProblemField) {
+	w.Header().Set("Location", "/api/v1/order/"+orderUID)
+	h.writeJSONResponse(w, http.StatusAccepted, writeAccepted{
+		Status:   "accepted",
+		OrderUID: orderUID,
+		Receipt:  receipt,
+		Warnings: warnings,
+	})
+}
+
+// decodeBody strictly decodes the request body into v. Unknown fields the
+// decoder only warns about are returned for the response.
+func (h *HTTPHandler) decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) ([]*models.FieldError, error) {
+	limit := int64(h.decoder.Options().MaxBytes)
+	if limit <= 0 {
+		limit = maxWriteBodyBytes
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, fmt.Errorf("%w: limit is %d bytes", errBodyTooLarge, maxBytesErr.Limit)
+		}
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, errEmptyBody
+	}
+	return h.decoder.Decode(body, v)
+}
+
+func (h *HTTPHandler) decodeWarnings(r *http.Request, warnings []*models.FieldError) []ProblemField {
+	var fields []ProblemField
+	for _, warning := range warnings {
+		h.log.WithContext(r.Context()).Warnf("Request decoding warning: %v", warning)
+		fields = append(fields, problemField(warning))
+	}
+	return fields
+}
@@ -0,0 +1,120 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Check probes one dependency. It returns optional details to include in the
+// report and a non-nil error when the dependency is not usable.
+type Check func(ctx context.Context) (map[string]interface{}, error)
+
+type ComponentStatus struct {
+	Status      string                 `json:"status"`
+	LatencyMs   float64                `json:"latency_ms"`
+	Error       string                 `json:"error,omitempty"`
+	LastError   string                 `json:"last_error,omitempty"`
+	LastErrorAt *time.Time             `json:"last_error_at,omitempty"`
+	Details     map[string]interface{} `json:"details,omitempty"`
+}
+
+type Report struct {
+	Status     string                     `json:"status"`
+	CheckedAt  time.Time                  `json:"checked_at"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+type lastError struct {
+	message string
+	at      time.Time
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+type Checker struct {
+	checks     []namedCheck
+	timeout    time.Duration
+	mutex      sync.Mutex
+	lastErrors map[string]lastError
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout:    timeout,
+		lastErrors: make(map[string]lastError),
+	}
+}
+
+func (c *Checker) Register(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Run executes all checks concurrently, each bounded by the checker timeout.
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{
+		Status:     StatusUp,
+		CheckedAt:  time.Now(),
+		Components: make(map[string]ComponentStatus, len(c.checks)),
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mutex sync.Mutex
+	)
+	for _, nc := range c.checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+
+			status := c.runCheck(ctx, nc)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			report.Components[nc.name] = status
+			if status.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}(nc)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (c *Checker) runCheck(ctx context.Context, nc namedCheck) ComponentStatus {
+	checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	started := time.Now()
+	details, err := nc.check(checkCtx)
+	status := ComponentStatus{
+		Status:    StatusUp,
+		LatencyMs: float64(time.Since(started).Microseconds()) / 1000,
+		Details:   details,
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+		c.lastErrors[nc.name] = lastError{message: err.Error(), at: time.Now()}
+	}
+	if last, ok := c.lastErrors[nc.name]; ok {
+		at := last.at
+		status.LastError = last.message
+		status.LastErrorAt = &at
+	}
+
+	return status
+}
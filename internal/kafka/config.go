@@ -0,0 +1,67 @@
+package kafka
+
+import (
+	"fmt"
+	"order-service/internal/config"
+
+	"github.com/IBM/sarama"
+)
+
+// NewSaramaConfig translates the service Kafka settings into a client
+// configuration shared by the consumer group and the producers.
+func NewSaramaConfig(cfg config.KafkaConfig) (*sarama.Config, error) {
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.ClientID = cfg.ClientID
+
+	if cfg.Version != "" {
+		version, err := sarama.ParseKafkaVersion(cfg.Version)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kafka version: %w", err)
+		}
+		saramaConfig.Version = version
+	}
+
+	switch cfg.InitialOffset {
+	case config.OffsetNewest:
+		saramaConfig.Consumer.Offsets.Initial = sarama.OffsetNewest
+	default:
+		saramaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+	}
+
+	switch cfg.RebalanceStrategy {
+	case config.RebalanceRange:
+		saramaConfig.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRange()}
+	case config.RebalanceSticky:
+		saramaConfig.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategySticky()}
+	case config.RebalanceCooperativeSticky:
+		saramaConfig.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyCooperativeSticky()}
+	default:
+		saramaConfig.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRoundRobin()}
+	}
+
+	switch cfg.IsolationLevel {
+	case config.IsolationReadCommitted:
+		saramaConfig.Consumer.IsolationLevel = sarama.ReadCommitted
+	default:
+		saramaConfig.Consumer.IsolationLevel = sarama.ReadUncommitted
+	}
+
+	saramaConfig.Consumer.Group.Session.Timeout = cfg.SessionTimeout
+	saramaConfig.Consumer.Group.Heartbeat.Interval = cfg.HeartbeatInterval
+	saramaConfig.Consumer.MaxProcessingTime = cfg.MaxProcessingTime
+	saramaConfig.Consumer.Fetch.Min = int32(cfg.FetchMinBytes)
+	saramaConfig.Consumer.Fetch.Default = int32(cfg.FetchDefaultBytes)
+	saramaConfig.Consumer.Fetch.Max = int32(cfg.FetchMaxBytes)
+
+	if err := applySASL(saramaConfig, cfg.SASL); err != nil {
+		return nil, err
+	}
+	if err := applyTLS(saramaConfig, cfg.TLS); err != nil {
+		return nil, err
+	}
+
+	if err := saramaConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid kafka client config: %w", err)
+	}
+	return saramaConfig, nil
+}
@@ -2,37 +2,65 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"order-service/internal/config"
+	"order-service/internal/correlation"
+	"order-service/internal/metrics"
 	"order-service/internal/models"
+	"order-service/internal/tracing"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Consumer struct {
-	consumerGroup sarama.ConsumerGroup
-	topics        []string
-	handlers      []MessageHandler
-	log           *logrus.Logger
-	ctx           context.Context
-	cancel        context.CancelFunc
-	wg            sync.WaitGroup
+	consumerGroup  sarama.ConsumerGroup
+	groupID        string
+	topics         []string
+	handlers       []MessageHandler
+	deadLetter     DeadLetterPublisher
+	retryPolicy    RetryPolicy
+	stallThreshold time.Duration
+	validator      *models.OrderValidator
+	decoder        *models.Decoder
+	schemas        *SchemaRegistry
+	state          groupState
+	log            *logrus.Logger
+	ctx            context.Context
+	cancel         context.CancelFunc
+	wg             sync.WaitGroup
 }
 
 type MessageHandler interface {
-	HandleOrder(order *models.Order) error
+	HandleOrder(ctx context.Context, order *models.Order) error
 }
 
-func NewConsumer(brokers []string, groupID string, topics []string, logger *logrus.Logger) (*Consumer, error) {
-	config := sarama.NewConfig()
-	config.Consumer.Group.Rebalance.Strategy = sarama.BalanceStrategyRoundRobin
-	config.Consumer.Offsets.Initial = sarama.OffsetOldest
-	config.Consumer.Group.Session.Timeout = 10000000000
-	config.Consumer.Group.Heartbeat.Interval = 3000000000
+// StatusHandler is implemented by handlers that also process order status
+// transitions, including cancellations.
+type StatusHandler interface {
+	HandleStatusChange(ctx context.Context, change *models.StatusChange) error
+}
+
+// DeleteHandler is implemented by handlers that also process order
+// deletions.
+type DeleteHandler interface {
+	HandleDelete(ctx context.Context, deletion *models.OrderDeletion) error
+}
+
+func NewConsumer(cfg config.KafkaConfig, logger *logrus.Logger) (*Consumer, error) {
+	saramaConfig, err := NewSaramaConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	consumerGroup, err := sarama.NewConsumerGroup(brokers, groupID, config)
+	consumerGroup, err := sarama.NewConsumerGroup(cfg.Brokers, cfg.GroupID, saramaConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create consumer group: %w", err)
 	}
@@ -41,7 +69,12 @@ func NewConsumer(brokers []string, groupID string, topics []string, logger *logr
 
 	return &Consumer{
 		consumerGroup: consumerGroup,
-		topics:        topics,
+		groupID:       cfg.GroupID,
+		topics:        cfg.Topics,
+		retryPolicy:   DefaultRetryPolicy(),
+		validator:     models.NewOrderValidator(),
+		decoder:       models.NewDecoder(models.DecodeOptions{UnknownFields: models.SeverityWarn}),
+		schemas:       DefaultSchemaRegistry(),
 		log:           logger,
 		ctx:           ctx,
 		cancel:        cancel,
@@ -52,6 +85,39 @@ func (c *Consumer) AddHandler(handler MessageHandler) {
 	c.handlers = append(c.handlers, handler)
 }
 
+func (c *Consumer) SetDeadLetterPublisher(publisher DeadLetterPublisher) {
+	c.deadLetter = publisher
+}
+
+// SetValidator replaces the default order validator, so the consumer and
+// the HTTP write path share rule severities.
+func (c *Consumer) SetValidator(validator *models.OrderValidator) {
+	c.validator = validator
+}
+
+// SetDecoder replaces the default decoder, which only warns about unknown
+// fields and enforces no limits.
+func (c *Consumer) SetDecoder(decoder *models.Decoder) {
+	c.decoder = decoder
+}
+
+// SetSchemaRegistry replaces the default registry of message types,
+// versions and upcasters.
+func (c *Consumer) SetSchemaRegistry(schemas *SchemaRegistry) {
+	c.schemas = schemas
+}
+
+// SetStallThreshold makes the health check fail when claimed partitions
+// have lag but no message arrived for longer than threshold. Zero disables
+// the check.
+func (c *Consumer) SetStallThreshold(threshold time.Duration) {
+	c.stallThreshold = threshold
+}
+
+func (c *Consumer) SetRetryPolicy(policy RetryPolicy) {
+	c.retryPolicy = policy
+}
+
 func (c *Consumer) Start() error {
 	c.log.Info("Starting Kafka consumer...")
 
@@ -66,6 +132,7 @@ func (c *Consumer) Start() error {
 			default:
 				if err := c.consumerGroup.Consume(c.ctx, c.topics, c); err != nil {
 					c.log.Errorf("Error from consumer: %v", err)
+					c.state.failed(err)
 				}
 			}
 		}
@@ -74,6 +141,7 @@ func (c *Consumer) Start() error {
 	go func() {
 		for err := range c.consumerGroup.Errors() {
 			c.log.Errorf("Consumer error: %v", err)
+			c.state.failed(err)
 		}
 	}()
 
@@ -88,17 +156,22 @@ func (c *Consumer) Stop() error {
 	return c.consumerGroup.Close()
 }
 
-func (c *Consumer) Setup(sarama.ConsumerGroupSession) error {
+func (c *Consumer) Setup(session sarama.ConsumerGroupSession) error {
 	c.log.Info("Consumer group session setup")
+	c.state.joined(session)
 	return nil
 }
 
 func (c *Consumer) Cleanup(sarama.ConsumerGroupSession) error {
 	c.log.Info("Consumer group session cleanup")
+	c.state.left()
 	return nil
 }
 
 func (c *Consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	c.state.claimStarted(claim)
+	defer c.state.claimEnded(claim)
+
 	for {
 		select {
 		case message := <-claim.Messages():
@@ -106,41 +179,272 @@ func (c *Consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim saram
 				return nil
 			}
 
-			c.log.Debugf("Received message from topic %s, partition %d, offset %d",
+			ctx, span := startMessageSpan(messageContext(session.Context(), message), message)
+			c.log.WithContext(ctx).Debugf("Received message from topic %s, partition %d, offset %d",
 				message.Topic, message.Partition, message.Offset)
+			c.state.messageReceived(message)
+
+			started := time.Now()
+			err := c.processMessage(ctx, message)
+			tracing.End(span, err)
+			if err != nil {
+				if session.Context().Err() != nil {
+					return nil
+				}
+
+				c.log.WithContext(ctx).Errorf("Failed to process message from topic %s, partition %d, offset %d: %v",
+					message.Topic, message.Partition, message.Offset, err)
+
+				if c.deadLetter == nil {
+					recordMessage(claim, message, resultFailed, started)
+					continue
+				}
+
+				if dlqErr := c.deadLetter.Publish(ctx, message, err); dlqErr != nil {
+					recordMessage(claim, message, resultFailed, started)
+					// Stop the claim so the message is redelivered after the
+					// rebalance instead of being committed past.
+					return fmt.Errorf("failed to publish message to dead-letter topic: %w", dlqErr)
+				}
 
-			if err := c.processMessage(message); err != nil {
-				c.log.Errorf("Failed to process message: %v", err)
-			} else {
 				session.MarkMessage(message, "")
+				recordMessage(claim, message, resultDeadLettered, started)
+				continue
 			}
 
+			session.MarkMessage(message, "")
+			recordMessage(claim, message, resultProcessed, started)
+
 		case <-session.Context().Done():
 			return nil
 		}
 	}
 }
 
-func (c *Consumer) processMessage(message *sarama.ConsumerMessage) error {
-	var order models.Order
-	if err := json.Unmarshal(message.Value, &order); err != nil {
-		return fmt.Errorf("failed to unmarshal message: %w", err)
+// startMessageSpan starts the span covering the processing of one message,
+// continuing the producer's trace when the message carries one.
+func startMessageSpan(ctx context.Context, message *sarama.ConsumerMessage) (context.Context, trace.Span) {
+	return tracing.Continue(ctx, headerValue(message, correlation.HeaderTraceparent), "process "+message.Topic,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingOperationTypeProcess,
+			semconv.MessagingDestinationName(message.Topic),
+			semconv.MessagingDestinationPartitionID(strconv.Itoa(int(message.Partition))),
+			semconv.MessagingKafkaOffset(int(message.Offset)),
+			semconv.MessagingKafkaMessageKey(string(message.Key)),
+			attribute.String("message_type", headerValue(message, HeaderMessageType)),
+		))
+}
+
+func (c *Consumer) processMessage(ctx context.Context, message *sarama.ConsumerMessage) error {
+	value, err := c.decode(ctx, message)
+	if err != nil {
+		return err
+	}
+
+	switch value := value.(type) {
+	case *models.Order:
+		return c.processOrder(ctx, value)
+	case *models.OrderCancellation:
+		return c.processCancel(ctx, value)
+	case *models.StatusChange:
+		return c.processStatus(ctx, value)
+	case *models.OrderDeletion:
+		return c.processDelete(ctx, value)
+	default:
+		return &ProcessingError{Class: ErrorClassDecode, Err: fmt.Errorf("no processor for %T", value)}
+	}
+}
+
+func (c *Consumer) processOrder(ctx context.Context, order *models.Order) error {
+	ctx = correlation.WithOrderUID(ctx, order.OrderUID)
+
+	result := c.validator.Validate(order)
+	logViolations(c.log.WithContext(ctx), result)
+	if err := result.Err(); err != nil {
+		c.log.WithContext(ctx).Warnf("Invalid order data: %v", err)
+		return &ProcessingError{Class: ErrorClassValidation, Err: err}
+	}
+
+	c.log.WithContext(ctx).Infof("Processing order: %s", order.OrderUID)
+
+	err := c.handleWithRetry(ctx, order.OrderUID, func(ctx context.Context) error {
+		return c.runHandlers(ctx, order)
+	})
+	if err != nil {
+		return err
+	}
+
+	c.log.WithContext(ctx).Infof("Order %s processed successfully", order.OrderUID)
+	return nil
+}
+
+func (c *Consumer) processCancel(ctx context.Context, cancel *models.OrderCancellation) error {
+	if err := cancel.Validate(); err != nil {
+		c.log.WithContext(ctx).Warnf("Invalid cancellation: %v", err)
+		return &ProcessingError{Class: ErrorClassValidation, Err: err}
+	}
+
+	return c.applyStatusChange(ctx, cancel.StatusChange())
+}
+
+func (c *Consumer) processStatus(ctx context.Context, change *models.StatusChange) error {
+	if err := change.Validate(); err != nil {
+		c.log.WithContext(ctx).Warnf("Invalid status change: %v", err)
+		return &ProcessingError{Class: ErrorClassValidation, Err: err}
+	}
+
+	return c.applyStatusChange(ctx, change)
+}
+
+func (c *Consumer) processDelete(ctx context.Context, deletion *models.OrderDeletion) error {
+	if err := deletion.Validate(); err != nil {
+		c.log.WithContext(ctx).Warnf("Invalid deletion: %v", err)
+		return &ProcessingError{Class: ErrorClassValidation, Err: err}
+	}
+
+	ctx = correlation.WithOrderUID(ctx, deletion.OrderUID)
+	c.log.WithContext(ctx).Infof("Processing deletion of order %s", deletion.OrderUID)
+
+	err := c.handleWithRetry(ctx, deletion.OrderUID, func(ctx context.Context) error {
+		return c.runDeleteHandlers(ctx, deletion)
+	})
+	if err != nil {
+		return err
 	}
 
-	if err := order.Validate(); err != nil {
-		c.log.Warnf("Invalid order data: %v", err)
+	c.log.WithContext(ctx).Infof("Deletion of order %s processed successfully", deletion.OrderUID)
+	return nil
+}
+
+func (c *Consumer) applyStatusChange(ctx context.Context, change *models.StatusChange) error {
+	ctx = correlation.WithOrderUID(ctx, change.OrderUID)
+	c.log.WithContext(ctx).Infof("Processing status change of order %s to %s", change.OrderUID, change.Status)
+
+	err := c.handleWithRetry(ctx, change.OrderUID, func(ctx context.Context) error {
+		return c.runStatusHandlers(ctx, change)
+	})
+	if err != nil {
 		return err
 	}
 
-	c.log.Infof("Processing order: %s", order.OrderUID)
+	c.log.WithContext(ctx).Infof("Status change of order %s to %s processed successfully", change.OrderUID, change.Status)
+	return nil
+}
+
+// decode unwraps the message envelope or headers, upcasts older schema
+// versions and strictly decodes the payload into the current model.
+// Unknown fields the decoder only warns about are logged.
+func (c *Consumer) decode(ctx context.Context, message *sarama.ConsumerMessage) (interface{}, error) {
+	ms, warnings, err := unwrapMessage(c.decoder, message)
+	var value interface{}
+	if err == nil {
+		var payloadWarnings []*models.FieldError
+		value, payloadWarnings, err = c.schemas.Decode(c.decoder, ms.EventType, ms.Version, ms.Payload)
+		warnings = append(warnings, payloadWarnings...)
+	}
+	for _, warning := range warnings {
+		c.log.WithContext(ctx).Warnf("Message decoding warning: %v", warning)
+	}
+	if err != nil {
+		return nil, &ProcessingError{Class: ErrorClassDecode,
+			Err: fmt.Errorf("failed to decode %s message (schema version %d, %s): %w", ms.EventType, ms.Version, ms.Format, err)}
+	}
+
+	recordSchema(ms)
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(
+		attribute.String("event_type", ms.EventType),
+		attribute.Int("schema_version", ms.Version),
+		attribute.String("schema_format", ms.Format),
+	)
+	if !ms.ProducedAt.IsZero() {
+		span.SetAttributes(attribute.String("produced_at", ms.ProducedAt.Format(time.RFC3339Nano)))
+	}
+	return value, nil
+}
+
+// logViolations counts every violation and logs the warnings; rejecting
+// violations are reported with the processing error.
+func logViolations(log *logrus.Entry, result models.ValidationResult) {
+	for _, violation := range result.Violations {
+		metrics.ValidationViolationsTotal.WithLabelValues(violation.Rule, string(violation.Severity), "kafka").Inc()
+	}
+	for _, warning := range result.Warnings() {
+		log.WithField("rule", warning.Rule).Warnf("Order validation warning: %v", warning.FieldError)
+	}
+}
+
+func (c *Consumer) handleWithRetry(ctx context.Context, orderUID string, handle func(ctx context.Context) error) error {
+	for retry := 0; ; retry++ {
+		err := handle(withRetryCount(ctx, retry))
+		if err == nil {
+			return nil
+		}
 
+		if !isRetryable(err) {
+			return &ProcessingError{Class: ErrorClassHandler, Err: err}
+		}
+
+		if retry+1 >= c.retryPolicy.MaxAttempts {
+			return &ProcessingError{Class: ErrorClassRetries,
+				Err: fmt.Errorf("giving up after %d attempts: %w", retry+1, err)}
+		}
+
+		delay := c.retryPolicy.backoff(retry + 1)
+		c.log.WithContext(ctx).Warnf("Transient error processing order %s (attempt %d/%d), retrying in %s: %v",
+			orderUID, retry+1, c.retryPolicy.MaxAttempts, delay, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Consumer) runHandlers(ctx context.Context, order *models.Order) error {
 	for _, handler := range c.handlers {
-		if err := handler.HandleOrder(&order); err != nil {
+		handlerCtx, span := tracing.Start(ctx, "HandleOrder", trace.WithAttributes(
+			attribute.String("handler", fmt.Sprintf("%T", handler)),
+			attribute.String("order_uid", order.OrderUID),
+			attribute.Int("retry", RetryCount(ctx)),
+		))
+		err := handler.HandleOrder(handlerCtx, order)
+		tracing.End(span, err)
+		if err != nil {
 			return fmt.Errorf("handler failed to process order: %w", err)
 		}
 	}
+	return nil
+}
 
-	c.log.Infof("Order %s processed successfully", order.OrderUID)
+func (c *Consumer) runStatusHandlers(ctx context.Context, change *models.StatusChange) error {
+	for _, handler := range c.handlers {
+		statusHandler, ok := handler.(StatusHandler)
+		if !ok {
+			continue
+		}
+		if err := statusHandler.HandleStatusChange(ctx, change); err != nil {
+			return fmt.Errorf("handler failed to process status change: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Consumer) runDeleteHandlers(ctx context.Context, deletion *models.OrderDeletion) error {
+	for _, handler := range c.handlers {
+		deleteHandler, ok := handler.(DeleteHandler)
+		if !ok {
+			continue
+		}
+		if err := deleteHandler.HandleDelete(ctx, deletion); err != nil {
+			return fmt.Errorf("handler failed to process deletion: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -151,13 +455,16 @@ type OrderHandler struct {
 }
 
 type OrderRepository interface {
-	SaveOrder(order *models.Order) error
-	GetOrder(orderUID string) (*models.Order, error)
+	SaveOrder(ctx context.Context, order *models.Order) error
+	GetOrder(ctx context.Context, orderUID string) (*models.Order, error)
+	ChangeOrderStatus(ctx context.Context, change *models.StatusChange) error
+	DeleteOrder(ctx context.Context, orderUID string) error
 }
 
 type OrderCache interface {
-	Set(orderUID string, order *models.Order)
-	Get(orderUID string) (*models.Order, bool)
+	Set(ctx context.Context, orderUID string, order *models.Order)
+	Get(ctx context.Context, orderUID string) (*models.Order, bool)
+	Delete(ctx context.Context, orderUID string)
 }
 
 func NewOrderHandler(repo OrderRepository, cache OrderCache, logger *logrus.Logger) *OrderHandler {
@@ -168,14 +475,47 @@ func NewOrderHandler(repo OrderRepository, cache OrderCache, logger *logrus.Logg
 	}
 }
 
-func (h *OrderHandler) HandleOrder(order *models.Order) error {
-	if err := h.repository.SaveOrder(order); err != nil {
-		h.log.Errorf("Failed to save order to database: %v", err)
+func (h *OrderHandler) HandleOrder(ctx context.Context, order *models.Order) error {
+	if err := h.repository.SaveOrder(ctx, order); err != nil {
+		if errors.Is(err, models.ErrStaleVersion) {
+			h.log.WithContext(ctx).Infof("Ignoring stale version %d of order %s", order.Version, order.OrderUID)
+			return nil
+		}
+		h.log.WithContext(ctx).Errorf("Failed to save order to database (retry %d): %v", RetryCount(ctx), err)
+		return err
+	}
+
+	h.cache.Set(ctx, order.OrderUID, order)
+
+	h.log.WithContext(ctx).Infof("Order %s handled successfully", order.OrderUID)
+	return nil
+}
+
+// HandleStatusChange applies a status transition and drops the cached copy
+// of the order so the next read sees the new status.
+func (h *OrderHandler) HandleStatusChange(ctx context.Context, change *models.StatusChange) error {
+	if err := h.repository.ChangeOrderStatus(ctx, change); err != nil {
+		h.log.WithContext(ctx).Errorf("Failed to change status of order %s to %s (retry %d): %v",
+			change.OrderUID, change.Status, RetryCount(ctx), err)
 		return err
 	}
 
-	h.cache.Set(order.OrderUID, order)
+	h.cache.Delete(ctx, change.OrderUID)
+	return nil
+}
+
+// HandleDelete removes the order and its cached copy. Deleting an order that
+// does not exist succeeds, so redelivered deletions are harmless.
+func (h *OrderHandler) HandleDelete(ctx context.Context, deletion *models.OrderDeletion) error {
+	if err := h.repository.DeleteOrder(ctx, deletion.OrderUID); err != nil {
+		if !errors.Is(err, models.ErrOrderNotFound) {
+			h.log.WithContext(ctx).Errorf("Failed to delete order %s (retry %d): %v",
+				deletion.OrderUID, RetryCount(ctx), err)
+			return err
+		}
+		h.log.WithContext(ctx).Infof("Order %s to delete does not exist", deletion.OrderUID)
+	}
 
-	h.log.Infof("Order %s handled successfully", order.OrderUID)
+	h.cache.Delete(ctx, deletion.OrderUID)
 	return nil
 }
@@ -0,0 +1,83 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"order-service/internal/config"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	HeaderOriginalTopic     = "x-original-topic"
+	HeaderOriginalPartition = "x-original-partition"
+	HeaderOriginalOffset    = "x-original-offset"
+	HeaderErrorClass        = "x-error-class"
+	HeaderErrorMessage      = "x-error-message"
+	HeaderFailedAt          = "x-failed-at"
+)
+
+type DeadLetterPublisher interface {
+	Publish(ctx context.Context, message *sarama.ConsumerMessage, cause error) error
+}
+
+type DeadLetterProducer struct {
+	producer sarama.SyncProducer
+	topic    string
+	log      *logrus.Logger
+}
+
+func NewDeadLetterProducer(cfg config.KafkaConfig, logger *logrus.Logger) (*DeadLetterProducer, error) {
+	producer, err := newSyncProducer(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dead-letter producer: %w", err)
+	}
+
+	return &DeadLetterProducer{
+		producer: producer,
+		topic:    cfg.DeadLetterTopic,
+		log:      logger,
+	}, nil
+}
+
+func (p *DeadLetterProducer) Publish(ctx context.Context, message *sarama.ConsumerMessage, cause error) error {
+	headers := make([]sarama.RecordHeader, 0, len(message.Headers)+6)
+	for _, header := range message.Headers {
+		if header != nil {
+			headers = append(headers, *header)
+		}
+	}
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte(HeaderOriginalTopic), Value: []byte(message.Topic)},
+		sarama.RecordHeader{Key: []byte(HeaderOriginalPartition), Value: []byte(strconv.FormatInt(int64(message.Partition), 10))},
+		sarama.RecordHeader{Key: []byte(HeaderOriginalOffset), Value: []byte(strconv.FormatInt(message.Offset, 10))},
+		sarama.RecordHeader{Key: []byte(HeaderErrorClass), Value: []byte(errorClass(cause))},
+		sarama.RecordHeader{Key: []byte(HeaderErrorMessage), Value: []byte(cause.Error())},
+		sarama.RecordHeader{Key: []byte(HeaderFailedAt), Value: []byte(time.Now().UTC().Format(time.RFC3339Nano))},
+	)
+
+	dlqMessage := &sarama.ProducerMessage{
+		Topic:   p.topic,
+		Value:   sarama.ByteEncoder(message.Value),
+		Headers: headers,
+	}
+	if message.Key != nil {
+		dlqMessage.Key = sarama.ByteEncoder(message.Key)
+	}
+
+	partition, offset, err := p.producer.SendMessage(dlqMessage)
+	if err != nil {
+		return fmt.Errorf("failed to send message to %s: %w", p.topic, err)
+	}
+
+	p.log.WithContext(ctx).Warnf("Message from topic %s, partition %d, offset %d moved to dead-letter topic %s (partition %d, offset %d)",
+		message.Topic, message.Partition, message.Offset, p.topic, partition, offset)
+	return nil
+}
+
+func (p *DeadLetterProducer) Close() error {
+	return p.producer.Close()
+}
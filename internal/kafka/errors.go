@@ -0,0 +1,32 @@
+package kafka
+
+import "errors"
+
+const (
+	ErrorClassDecode     = "decode"
+	ErrorClassValidation = "validation"
+	ErrorClassHandler    = "handler"
+	ErrorClassRetries    = "retries_exhausted"
+	ErrorClassUnknown    = "unknown"
+)
+
+type ProcessingError struct {
+	Class string
+	Err   error
+}
+
+func (e *ProcessingError) Error() string {
+	return e.Class + ": " + e.Err.Error()
+}
+
+func (e *ProcessingError) Unwrap() error {
+	return e.Err
+}
+
+func errorClass(err error) string {
+	var processingErr *ProcessingError
+	if errors.As(err, &processingErr) {
+		return processingErr.Class
+	}
+	return ErrorClassUnknown
+}
@@ -0,0 +1,82 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"order-service/internal/config"
+	"order-service/internal/correlation"
+	"order-service/internal/models"
+	"order-service/internal/tracing"
+	"strconv"
+
+	"github.com/IBM/sarama"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	HeaderEventType = "x-event-type"
+	HeaderEventID   = "x-event-id"
+)
+
+// EventProducer publishes order-change events for downstream consumers,
+// keyed by order UID so events of one order keep their order.
+type EventProducer struct {
+	producer sarama.SyncProducer
+	topic    string
+	log      *logrus.Logger
+}
+
+func NewEventProducer(cfg config.KafkaConfig, topic string, logger *logrus.Logger) (*EventProducer, error) {
+	producer, err := newSyncProducer(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event producer: %w", err)
+	}
+
+	return &EventProducer{
+		producer: producer,
+		topic:    topic,
+		log:      logger,
+	}, nil
+}
+
+func (p *EventProducer) PublishEvent(event *models.OutboxEvent) (err error) {
+	value, err := json.Marshal(event.OrderEvent())
+	if err != nil {
+		return fmt.Errorf("failed to marshal event %d: %w", event.ID, err)
+	}
+
+	ctx := correlation.NewContext(context.Background(), eventIDs(event))
+	ctx, span := startPublishSpan(tracing.ContinueFrom(ctx, event.Traceparent), p.topic, event.OrderUID)
+	defer func() { tracing.End(span, err) }()
+
+	ids := correlation.FromContext(ctx)
+	partition, offset, err := p.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: p.topic,
+		Key:   sarama.StringEncoder(event.OrderUID),
+		Value: sarama.ByteEncoder(value),
+		Headers: append([]sarama.RecordHeader{
+			{Key: []byte(HeaderEventType), Value: []byte(event.EventType)},
+			{Key: []byte(HeaderEventID), Value: []byte(strconv.FormatInt(event.ID, 10))},
+		}, correlationHeaders(ids)...),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send event %d to %s: %w", event.ID, p.topic, err)
+	}
+
+	p.log.WithFields(ids.Fields()).Debugf("Published %s event %d for order %s to topic %s (partition %d, offset %d)",
+		event.EventType, event.ID, event.OrderUID, p.topic, partition, offset)
+	return nil
+}
+
+// eventIDs continues the trace of the change that recorded the event in a
+// new span.
+func eventIDs(event *models.OutboxEvent) correlation.IDs {
+	ids := correlation.Incoming(event.RequestID, event.Traceparent)
+	ids.OrderUID = event.OrderUID
+	return ids
+}
+
+func (p *EventProducer) Close() error {
+	return p.producer.Close()
+}
@@ -0,0 +1,34 @@
+package kafka
+
+import (
+	"order-service/internal/metrics"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+const (
+	resultProcessed    = "processed"
+	resultDeadLettered = "dead_lettered"
+	resultFailed       = "failed"
+)
+
+func recordMessage(claim sarama.ConsumerGroupClaim, message *sarama.ConsumerMessage, result string, started time.Time) {
+	partition := strconv.FormatInt(int64(message.Partition), 10)
+
+	metrics.KafkaMessagesTotal.WithLabelValues(message.Topic, partition, result).Inc()
+	metrics.KafkaProcessingDuration.WithLabelValues(message.Topic, partition).Observe(time.Since(started).Seconds())
+
+	lag := claim.HighWaterMarkOffset() - message.Offset - 1
+	if lag < 0 {
+		lag = 0
+	}
+	metrics.KafkaConsumerLag.WithLabelValues(message.Topic, partition).Set(float64(lag))
+}
+
+// recordSchema counts decoded messages by schema, so legacy producers can
+// be found before support for their version is dropped.
+func recordSchema(ms MessageSchema) {
+	metrics.KafkaMessageSchemasTotal.WithLabelValues(ms.EventType, strconv.Itoa(ms.Version), ms.Format).Inc()
+}
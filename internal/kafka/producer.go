@@ -0,0 +1,179 @@
+package kafka
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"order-service/internal/config"
+	"order-service/internal/correlation"
+	"order-service/internal/models"
+	"order-service/internal/tracing"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/sirupsen/logrus"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	HeaderMessageType = "x-message-type"
+	HeaderTrackingID  = "x-tracking-id"
+
+	MessageTypeOrder  = "order"
+	MessageTypeCancel = "order.cancel"
+	MessageTypeDelete = "order.delete"
+	MessageTypeStatus = "order.status"
+)
+
+// Receipt identifies a message accepted by Kafka so callers can track it
+// until the consumer applies it.
+type Receipt struct {
+	TrackingID string `json:"tracking_id"`
+	Topic      string `json:"topic"`
+	Partition  int32  `json:"partition"`
+	Offset     int64  `json:"offset"`
+}
+
+// OrderProducer publishes order writes to the topic the service consumes,
+// keyed by order UID so all writes to one order stay in one partition.
+type OrderProducer struct {
+	producer sarama.SyncProducer
+	topic    string
+	log      *logrus.Logger
+}
+
+func NewOrderProducer(cfg config.KafkaConfig, logger *logrus.Logger) (*OrderProducer, error) {
+	producer, err := newSyncProducer(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create order producer: %w", err)
+	}
+
+	return &OrderProducer{
+		producer: producer,
+		topic:    cfg.ProduceTopic,
+		log:      logger,
+	}, nil
+}
+
+func (p *OrderProducer) PublishOrder(ctx context.Context, order *models.Order) (Receipt, error) {
+	return p.publish(ctx, order.OrderUID, MessageTypeOrder, OrderSchemaVersion, order)
+}
+
+func (p *OrderProducer) PublishCancel(ctx context.Context, cancel *models.OrderCancellation) (Receipt, error) {
+	return p.publish(ctx, cancel.OrderUID, MessageTypeCancel, CancelSchemaVersion, cancel)
+}
+
+func (p *OrderProducer) PublishDelete(ctx context.Context, deletion *models.OrderDeletion) (Receipt, error) {
+	return p.publish(ctx, deletion.OrderUID, MessageTypeDelete, DeleteSchemaVersion, deletion)
+}
+
+// publish sends payload bare, with its type and schema version in headers,
+// so consumers that predate versioning can still read it.
+func (p *OrderProducer) publish(ctx context.Context, orderUID, messageType string, schemaVersion int, payload interface{}) (_ Receipt, err error) {
+	ctx, span := startPublishSpan(ctx, p.topic, orderUID)
+	defer func() { tracing.End(span, err) }()
+
+	value, err := json.Marshal(payload)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("failed to marshal %s message: %w", messageType, err)
+	}
+
+	trackingID, err := newTrackingID()
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	partition, offset, err := p.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: p.topic,
+		Key:   sarama.StringEncoder(orderUID),
+		Value: sarama.ByteEncoder(value),
+		Headers: append([]sarama.RecordHeader{
+			{Key: []byte(HeaderMessageType), Value: []byte(messageType)},
+			{Key: []byte(HeaderSchemaVersion), Value: []byte(strconv.Itoa(schemaVersion))},
+			{Key: []byte(HeaderProducedAt), Value: []byte(time.Now().UTC().Format(time.RFC3339Nano))},
+			{Key: []byte(HeaderTrackingID), Value: []byte(trackingID)},
+		}, correlationHeaders(correlation.FromContext(ctx))...),
+	})
+	if err != nil {
+		return Receipt{}, fmt.Errorf("failed to send message to %s: %w", p.topic, err)
+	}
+
+	p.log.WithContext(ctx).Infof("Published %s message for order %s to topic %s (partition %d, offset %d, tracking %s)",
+		messageType, orderUID, p.topic, partition, offset, trackingID)
+
+	return Receipt{
+		TrackingID: trackingID,
+		Topic:      p.topic,
+		Partition:  partition,
+		Offset:     offset,
+	}, nil
+}
+
+func (p *OrderProducer) Close() error {
+	return p.producer.Close()
+}
+
+func newSyncProducer(cfg config.KafkaConfig) (sarama.SyncProducer, error) {
+	saramaConfig, err := NewSaramaConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	saramaConfig.Producer.RequiredAcks = sarama.WaitForAll
+	saramaConfig.Producer.Retry.Max = 5
+	saramaConfig.Producer.Return.Successes = true
+
+	return sarama.NewSyncProducer(cfg.Brokers, saramaConfig)
+}
+
+func newTrackingID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate tracking ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func startPublishSpan(ctx context.Context, topic, key string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "publish "+topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(topic),
+			semconv.MessagingKafkaMessageKey(key),
+		))
+}
+
+// correlationHeaders carries the request ID and trace context of the
+// producing request to the consumer.
+func correlationHeaders(ids correlation.IDs) []sarama.RecordHeader {
+	var headers []sarama.RecordHeader
+	if ids.RequestID != "" {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(correlation.KafkaHeaderRequestID), Value: []byte(ids.RequestID)})
+	}
+	if traceparent := ids.Trace.String(); traceparent != "" {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(correlation.HeaderTraceparent), Value: []byte(traceparent)})
+	}
+	return headers
+}
+
+// messageContext returns ctx carrying the IDs sent with the message, or new
+// ones for messages produced without them.
+func messageContext(ctx context.Context, message *sarama.ConsumerMessage) context.Context {
+	ids := correlation.Incoming(headerValue(message, correlation.KafkaHeaderRequestID),
+		headerValue(message, correlation.HeaderTraceparent))
+	ids.OrderUID = string(message.Key)
+	return correlation.NewContext(ctx, ids)
+}
+
+func headerValue(message *sarama.ConsumerMessage, key string) string {
+	for _, header := range message.Headers {
+		if header != nil && string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
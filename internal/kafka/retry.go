@@ -0,0 +1,54 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"order-service/internal/models"
+	"time"
+)
+
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+	}
+}
+
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < retry; i++ {
+		delay *= p.Multiplier
+		if p.MaxBackoff > 0 && delay >= float64(p.MaxBackoff) {
+			return p.MaxBackoff
+		}
+	}
+	return time.Duration(delay)
+}
+
+func isRetryable(err error) bool {
+	return errors.Is(err, models.ErrTransient)
+}
+
+type retryCountKey struct{}
+
+func withRetryCount(ctx context.Context, retry int) context.Context {
+	return context.WithValue(ctx, retryCountKey{}, retry)
+}
+
+// RetryCount returns how many times the current message has already been
+// retried. It is zero on the first delivery attempt.
+func RetryCount(ctx context.Context) int {
+	if retry, ok := ctx.Value(retryCountKey{}).(int); ok {
+		return retry
+	}
+	return 0
+}
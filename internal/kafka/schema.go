@@ -0,0 +1,262 @@
+package kafka
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"order-service/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Headers that describe the schema of a bare payload. The event type is
+// carried by HeaderMessageType.
+const (
+	HeaderSchemaVersion = "x-schema-version"
+	HeaderProducedAt    = "x-produced-at"
+)
+
+// Current schema versions of the consumed message types. Bump a version
+// together with the model and register an upcaster from the previous one
+// in DefaultSchemaRegistry.
+const (
+	// OrderSchemaVersion 2 is the order with its optimistic version and
+	// lifecycle status; version 1 is the format that predates them.
+	OrderSchemaVersion  = 2
+	CancelSchemaVersion = 1
+	StatusSchemaVersion = 1
+	DeleteSchemaVersion = 1
+)
+
+// legacySchemaVersion is assumed for messages that do not state a version:
+// bare payloads from producers that predate versioning. Orders of this
+// version are upcast to OrderSchemaVersion.
+const legacySchemaVersion = 1
+
+// Formats a message schema can be declared in, used as a metric label.
+const (
+	FormatEnvelope = "envelope"
+	FormatHeaders  = "headers"
+	FormatBare     = "bare"
+)
+
+var ErrUnsupportedSchema = errors.New("unsupported message schema")
+
+// Envelope wraps a payload with its type and schema version. Messages may
+// be sent enveloped, or bare with the same data in headers.
+type Envelope struct {
+	SchemaVersion int             `json:"schema_version"`
+	EventType     string          `json:"event_type,omitempty"`
+	ProducedAt    time.Time       `json:"produced_at"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// Upcaster rewrites a payload of one schema version into the next one.
+type Upcaster func(payload json.RawMessage) (json.RawMessage, error)
+
+// SchemaRegistry knows, for every event type, the model its current
+// version decodes into and the upcasters that bring older versions up to
+// date.
+type SchemaRegistry struct {
+	schemas map[string]*schema
+}
+
+type schema struct {
+	current   int
+	newValue  func() interface{}
+	upcasters map[int]Upcaster
+}
+
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{schemas: make(map[string]*schema)}
+}
+
+// DefaultSchemaRegistry returns the registry of the message types the
+// consumer handles.
+func DefaultSchemaRegistry() *SchemaRegistry {
+	r := NewSchemaRegistry()
+	r.Register(MessageTypeOrder, OrderSchemaVersion, func() interface{} { return &models.Order{} })
+	r.Register(MessageTypeCancel, CancelSchemaVersion, func() interface{} { return &models.OrderCancellation{} })
+	r.Register(MessageTypeStatus, StatusSchemaVersion, func() interface{} { return &models.StatusChange{} })
+	r.Register(MessageTypeDelete, DeleteSchemaVersion, func() interface{} { return &models.OrderDeletion{} })
+	r.RegisterUpcaster(MessageTypeOrder, 1, upcastOrderV1)
+	return r
+}
+
+// upcastOrderV1 drops the order-level status of version 1 payloads. Orders
+// had no status then, and legacy producers put unrelated values there,
+// such as the numeric item statuses; since version 2 the status is only
+// changed by order.status messages. Payloads without a status are returned
+// unchanged, so decode errors still point into the original message.
+func upcastOrderV1(payload json.RawMessage) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil || fields == nil {
+		// Not an object: leave the error to the strict decoder.
+		return payload, nil
+	}
+
+	changed := false
+	for key := range fields {
+		if strings.EqualFold(key, "status") {
+			delete(fields, key)
+			changed = true
+		}
+	}
+	if !changed {
+		return payload, nil
+	}
+	return json.Marshal(fields)
+}
+
+// Register adds an event type whose current version decodes into the
+// value returned by newValue. It panics if the type is already registered.
+func (r *SchemaRegistry) Register(eventType string, current int, newValue func() interface{}) {
+	if _, ok := r.schemas[eventType]; ok {
+		panic(fmt.Sprintf("kafka: schema of %q registered twice", eventType))
+	}
+	if current < 1 {
+		panic(fmt.Sprintf("kafka: schema version %d of %q must be positive", current, eventType))
+	}
+	r.schemas[eventType] = &schema{current: current, newValue: newValue, upcasters: make(map[int]Upcaster)}
+}
+
+// RegisterUpcaster adds the conversion of eventType payloads from version
+// from to from+1. It panics on types that are not registered and on
+// versions that are not older than the current one.
+func (r *SchemaRegistry) RegisterUpcaster(eventType string, from int, upcast Upcaster) {
+	s, ok := r.schemas[eventType]
+	if !ok {
+		panic(fmt.Sprintf("kafka: upcaster for unregistered schema %q", eventType))
+	}
+	if from < 1 || from >= s.current {
+		panic(fmt.Sprintf("kafka: upcaster from version %d of %q, current version is %d", from, eventType, s.current))
+	}
+	s.upcasters[from] = upcast
+}
+
+// Decode upcasts a payload of the given version to the current one and
+// strictly decodes it into the registered model. Decode errors of upcast
+// payloads locate the upcaster's output, not the original payload.
+func (r *SchemaRegistry) Decode(decoder *models.Decoder, eventType string, version int, payload []byte) (interface{}, []*models.FieldError, error) {
+	s, ok := r.schemas[eventType]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: unknown message type %q, known types are %s",
+			ErrUnsupportedSchema, eventType, strings.Join(r.eventTypes(), ", "))
+	}
+	if version < 1 || version > s.current {
+		return nil, nil, fmt.Errorf("%w: %s version %d, supported versions are 1-%d",
+			ErrUnsupportedSchema, eventType, version, s.current)
+	}
+
+	for ; version < s.current; version++ {
+		upcast, ok := s.upcasters[version]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: no upcaster of %s from version %d", ErrUnsupportedSchema, eventType, version)
+		}
+		upcasted, err := upcast(payload)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to upcast %s from version %d: %w", eventType, version, err)
+		}
+		payload = upcasted
+	}
+
+	value := s.newValue()
+	warnings, err := decoder.Decode(payload, value)
+	if err != nil {
+		return nil, warnings, err
+	}
+	return value, warnings, nil
+}
+
+func (r *SchemaRegistry) eventTypes() []string {
+	types := make([]string, 0, len(r.schemas))
+	for eventType := range r.schemas {
+		types = append(types, eventType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// MessageSchema is the type, version and payload of a message, taken from
+// its envelope or headers.
+type MessageSchema struct {
+	EventType  string
+	Version    int
+	ProducedAt time.Time
+	Format     string
+	Payload    []byte
+}
+
+// envelopeProbe tells enveloped messages from bare payloads: no model has
+// both keys.
+type envelopeProbe struct {
+	SchemaVersion json.RawMessage `json:"schema_version"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// unwrapMessage reads the schema of a message. The envelope wins over
+// headers, but a header that contradicts it is an error. Messages that
+// declare nothing are legacy orders.
+func unwrapMessage(decoder *models.Decoder, message *sarama.ConsumerMessage) (MessageSchema, []*models.FieldError, error) {
+	ms := MessageSchema{
+		EventType: headerValue(message, HeaderMessageType),
+		Format:    FormatBare,
+		Payload:   message.Value,
+	}
+
+	if value := headerValue(message, HeaderSchemaVersion); value != "" {
+		version, err := strconv.Atoi(value)
+		if err != nil {
+			return ms, nil, fmt.Errorf("%w: header %s %q is not a number", ErrUnsupportedSchema, HeaderSchemaVersion, value)
+		}
+		ms.Version = version
+		ms.Format = FormatHeaders
+	}
+	if value := headerValue(message, HeaderProducedAt); value != "" {
+		producedAt, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return ms, nil, fmt.Errorf("%w: header %s %q is not an RFC 3339 time", ErrUnsupportedSchema, HeaderProducedAt, value)
+		}
+		ms.ProducedAt = producedAt
+	}
+
+	var warnings []*models.FieldError
+	var probe envelopeProbe
+	if json.Unmarshal(message.Value, &probe) == nil && probe.SchemaVersion != nil && probe.Payload != nil {
+		var envelope Envelope
+		var err error
+		if warnings, err = decoder.Decode(message.Value, &envelope); err != nil {
+			return ms, warnings, fmt.Errorf("invalid envelope: %w", err)
+		}
+		if ms.EventType != "" && envelope.EventType != "" && ms.EventType != envelope.EventType {
+			return ms, warnings, fmt.Errorf("%w: event_type %q contradicts header %s %q",
+				ErrUnsupportedSchema, envelope.EventType, HeaderMessageType, ms.EventType)
+		}
+		if ms.Version != 0 && ms.Version != envelope.SchemaVersion {
+			return ms, warnings, fmt.Errorf("%w: schema_version %d contradicts header %s %d",
+				ErrUnsupportedSchema, envelope.SchemaVersion, HeaderSchemaVersion, ms.Version)
+		}
+
+		if envelope.EventType != "" {
+			ms.EventType = envelope.EventType
+		}
+		if !envelope.ProducedAt.IsZero() {
+			ms.ProducedAt = envelope.ProducedAt
+		}
+		ms.Version = envelope.SchemaVersion
+		ms.Format = FormatEnvelope
+		ms.Payload = envelope.Payload
+	}
+
+	if ms.EventType == "" {
+		ms.EventType = MessageTypeOrder
+	}
+	if ms.Format == FormatBare {
+		ms.Version = legacySchemaVersion
+	}
+	return ms, warnings, nil
+}
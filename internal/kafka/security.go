@@ -0,0 +1,76 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"order-service/internal/config"
+	"os"
+
+	"github.com/IBM/sarama"
+)
+
+func applySASL(saramaConfig *sarama.Config, cfg config.KafkaSASLConfig) error {
+	if cfg.Mechanism == "" {
+		return nil
+	}
+
+	saramaConfig.Net.SASL.Enable = true
+	saramaConfig.Net.SASL.Handshake = true
+	saramaConfig.Net.SASL.User = cfg.Username
+	saramaConfig.Net.SASL.Password = cfg.Password
+
+	switch cfg.Mechanism {
+	case config.SASLPlain:
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+	case config.SASLScramSHA256:
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+		saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{hashGenerator: scramSHA256}
+		}
+	case config.SASLScramSHA512:
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+		saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{hashGenerator: scramSHA512}
+		}
+	default:
+		return fmt.Errorf("unsupported SASL mechanism %q", cfg.Mechanism)
+	}
+	return nil
+}
+
+func applyTLS(saramaConfig *sarama.Config, cfg config.KafkaTLSConfig) error {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		caPEM, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read kafka CA bundle %s: %w", cfg.CAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return fmt.Errorf("kafka CA bundle %s contains no PEM certificates", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load kafka client certificate %s and key %s: %w", cfg.CertFile, cfg.KeyFile, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	saramaConfig.Net.TLS.Enable = true
+	saramaConfig.Net.TLS.Config = tlsConfig
+	return nil
+}
@@ -0,0 +1,159 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+type groupState struct {
+	mutex         sync.Mutex
+	member        bool
+	memberID      string
+	generationID  int32
+	claims        map[string][]int32
+	progress      map[string]*claimProgress
+	lastSessionAt time.Time
+	lastMessageAt time.Time
+	lastError     error
+	lastErrorAt   time.Time
+}
+
+// claimProgress is the next offset to be read from a claimed partition,
+// compared with its high water mark to tell a stalled consumer from an idle
+// one.
+type claimProgress struct {
+	claim sarama.ConsumerGroupClaim
+	next  int64
+}
+
+func claimKey(topic string, partition int32) string {
+	return fmt.Sprintf("%s/%d", topic, partition)
+}
+
+func (s *groupState) joined(session sarama.ConsumerGroupSession) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.member = true
+	s.memberID = session.MemberID()
+	s.generationID = session.GenerationID()
+	s.claims = session.Claims()
+	s.lastSessionAt = time.Now()
+}
+
+func (s *groupState) left() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.member = false
+	s.claims = nil
+	s.progress = nil
+}
+
+func (s *groupState) claimStarted(claim sarama.ConsumerGroupClaim) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.progress == nil {
+		s.progress = make(map[string]*claimProgress)
+	}
+	s.progress[claimKey(claim.Topic(), claim.Partition())] = &claimProgress{claim: claim, next: claim.InitialOffset()}
+}
+
+func (s *groupState) claimEnded(claim sarama.ConsumerGroupClaim) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.progress, claimKey(claim.Topic(), claim.Partition()))
+}
+
+func (s *groupState) messageReceived(message *sarama.ConsumerMessage) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.lastMessageAt = time.Now()
+	if progress := s.progress[claimKey(message.Topic, message.Partition)]; progress != nil {
+		progress.next = message.Offset + 1
+	}
+}
+
+// lag sums the messages waiting in the claimed partitions. Partitions that
+// start from an offset sentinel have an unknown lag until their first
+// message arrives; known is false when no partition has a known lag.
+func (s *groupState) lag() (lag int64, known bool) {
+	for _, progress := range s.progress {
+		if progress.next < 0 {
+			continue
+		}
+		known = true
+		if waiting := progress.claim.HighWaterMarkOffset() - progress.next; waiting > 0 {
+			lag += waiting
+		}
+	}
+	return lag, known
+}
+
+func (s *groupState) failed(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.lastError = err
+	s.lastErrorAt = time.Now()
+}
+
+// HealthCheck reports whether the consumer currently holds a group session.
+// An error seen after the latest session was established also fails the
+// check, since it usually means the session is being torn down. With a
+// stall threshold set, the check also fails when claimed partitions have
+// lag but no message arrived within the threshold.
+func (c *Consumer) HealthCheck(ctx context.Context) (map[string]interface{}, error) {
+	s := &c.state
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	details := map[string]interface{}{
+		"group_id":      c.groupID,
+		"topics":        c.topics,
+		"member":        s.member,
+		"member_id":     s.memberID,
+		"generation_id": s.generationID,
+		"claims":        s.claims,
+	}
+	if !s.lastSessionAt.IsZero() {
+		details["last_session_at"] = s.lastSessionAt
+	}
+	if !s.lastMessageAt.IsZero() {
+		details["last_message_at"] = s.lastMessageAt
+	}
+
+	if !s.member {
+		if s.lastError != nil {
+			return details, fmt.Errorf("consumer is not a member of group %s: %w", c.groupID, s.lastError)
+		}
+		return details, fmt.Errorf("consumer is not a member of group %s", c.groupID)
+	}
+	if s.lastError != nil && s.lastErrorAt.After(s.lastSessionAt) {
+		return details, fmt.Errorf("consumer error since last rebalance: %w", s.lastError)
+	}
+
+	lag, known := s.lag()
+	if known {
+		details["lag"] = lag
+	}
+	if c.stallThreshold > 0 && lag > 0 {
+		lastActivity := s.lastMessageAt
+		if s.lastSessionAt.After(lastActivity) {
+			lastActivity = s.lastSessionAt
+		}
+		if idle := time.Since(lastActivity); idle > c.stallThreshold {
+			return details, fmt.Errorf("consumer is stalled: lag is %d but no message arrived for %s",
+				lag, idle.Round(time.Second))
+		}
+	}
+
+	return details, nil
+}
@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"order-service/internal/cache"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "order_service"
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	KafkaMessagesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "messages_total",
+		Help:      "Kafka messages consumed by topic, partition and result.",
+	}, []string{"topic", "partition", "result"})
+
+	KafkaProcessingDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "processing_duration_seconds",
+		Help:      "Time spent processing a Kafka message, including retries.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"topic", "partition"})
+
+	KafkaConsumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "consumer_lag",
+		Help:      "Messages between the last processed offset and the partition high water mark.",
+	}, []string{"topic", "partition"})
+
+	KafkaMessageSchemasTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "kafka",
+		Name:      "message_schemas_total",
+		Help:      "Decoded Kafka messages by event type, schema version and format: envelope, headers or bare.",
+	}, []string{"event_type", "schema_version", "format"})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Repository operation latency.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	DBQueryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_errors_total",
+		Help:      "Failed repository operations.",
+	}, []string{"operation"})
+
+	OutboxEventsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "events_total",
+		Help:      "Outbox publish attempts by event type and result.",
+	}, []string{"event_type", "result"})
+
+	OutboxDelay = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "publish_delay_seconds",
+		Help:      "Time between an order change and the publication of its event.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	ValidationViolationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "validation",
+		Name:      "violations_total",
+		Help:      "Order validation rule violations by rule, severity and source.",
+	}, []string{"rule", "severity", "source"})
+)
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+func RegisterDBStats(db *sql.DB, dbName string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, dbName))
+}
+
+func RegisterCache(stats func() cache.Stats) {
+	prometheus.MustRegister(&cacheCollector{stats: stats})
+}
+
+var (
+	cacheSizeDesc        = prometheus.NewDesc(namespace+"_cache_size", "Orders currently held in the cache.", nil, nil)
+	cacheBytesDesc       = prometheus.NewDesc(namespace+"_cache_bytes", "Approximate bytes held by the cache.", nil, nil)
+	cacheHitRatioDesc    = prometheus.NewDesc(namespace+"_cache_hit_ratio", "Cache hits divided by lookups.", nil, nil)
+	cacheLookupsDesc     = prometheus.NewDesc(namespace+"_cache_lookups_total", "Cache lookups by source and result.", []string{"source", "result"}, nil)
+	cacheEvictionsDesc   = prometheus.NewDesc(namespace+"_cache_evictions_total", "Entries evicted to stay within cache limits.", nil, nil)
+	cacheExpirationsDesc = prometheus.NewDesc(namespace+"_cache_expirations_total", "Entries removed after their TTL.", nil, nil)
+)
+
+type cacheCollector struct {
+	stats func() cache.Stats
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheSizeDesc
+	ch <- cacheBytesDesc
+	ch <- cacheHitRatioDesc
+	ch <- cacheLookupsDesc
+	ch <- cacheEvictionsDesc
+	ch <- cacheExpirationsDesc
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+
+	ch <- prometheus.MustNewConstMetric(cacheSizeDesc, prometheus.GaugeValue, float64(stats.Size))
+	ch <- prometheus.MustNewConstMetric(cacheBytesDesc, prometheus.GaugeValue, float64(stats.Bytes))
+	ch <- prometheus.MustNewConstMetric(cacheHitRatioDesc, prometheus.GaugeValue, stats.HitRatio)
+	ch <- prometheus.MustNewConstMetric(cacheEvictionsDesc, prometheus.CounterValue, float64(stats.Evictions))
+	ch <- prometheus.MustNewConstMetric(cacheExpirationsDesc, prometheus.CounterValue, float64(stats.Expirations))
+	for source, sourceStats := range stats.Sources {
+		ch <- prometheus.MustNewConstMetric(cacheLookupsDesc, prometheus.CounterValue, float64(sourceStats.Hits), source, "hit")
+		ch <- prometheus.MustNewConstMetric(cacheLookupsDesc, prometheus.CounterValue, float64(sourceStats.Misses), source, "miss")
+	}
+}
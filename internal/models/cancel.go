@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// OrderCancellation asks for an order to be cancelled. It travels through
+// the orders topic alongside full order payloads.
+type OrderCancellation struct {
+	OrderUID    string    `json:"order_uid"`
+	Reason      string    `json:"reason,omitempty"`
+	RequestedBy string    `json:"requested_by,omitempty"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// StatusChange converts the cancellation into a transition to cancelled.
+func (c *OrderCancellation) StatusChange() *StatusChange {
+	changedBy := c.RequestedBy
+	if changedBy == "" {
+		changedBy = "unknown"
+	}
+	return &StatusChange{
+		OrderUID:  c.OrderUID,
+		Status:    StatusCancelled,
+		ChangedBy: changedBy,
+		Reason:    c.Reason,
+		ChangedAt: c.RequestedAt,
+	}
+}
+
+func (c *OrderCancellation) Validate() error {
+	if c.OrderUID == "" {
+		return NewFieldError("order_uid", ErrInvalidOrderUID)
+	}
+	return nil
+}
+
+// OrderDeletion asks for an order and its status history to be removed. It
+// travels through the orders topic like cancellations.
+type OrderDeletion struct {
+	OrderUID    string    `json:"order_uid"`
+	Reason      string    `json:"reason,omitempty"`
+	RequestedBy string    `json:"requested_by,omitempty"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+func (d *OrderDeletion) Validate() error {
+	if d.OrderUID == "" {
+		return NewFieldError("order_uid", ErrInvalidOrderUID)
+	}
+	return nil
+}
@@ -0,0 +1,583 @@
+package models
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DecodeOptions control strict decoding. Zero limits disable the checks.
+type DecodeOptions struct {
+	// UnknownFields is the severity of fields the target type does not
+	// have: reject fails decoding, warn reports them as warnings.
+	UnknownFields Severity
+	// MaxBytes bounds the size of a payload.
+	MaxBytes int
+	// MaxItems bounds the length of every JSON array, for orders the items
+	// list.
+	MaxItems int
+}
+
+// DecodeError locates a decoding problem in the payload.
+type DecodeError struct {
+	Offset int64
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%v at byte offset %d", e.Err, e.Offset)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Decoder decodes JSON payloads strictly. Unlike json.Unmarshal it
+// reports every unknown field, type mismatch and oversized array with its
+// JSON path and byte offset instead of silently leaving zero values.
+type Decoder struct {
+	opts DecodeOptions
+}
+
+func NewDecoder(opts DecodeOptions) *Decoder {
+	return &Decoder{opts: opts}
+}
+
+func (d *Decoder) Options() DecodeOptions {
+	return d.opts
+}
+
+// Decode fills v from data. Violations are returned as FieldErrors joined
+// into err; unknown fields are returned as warnings instead when their
+// severity is warn. Malformed JSON, unknown fields and type mismatches
+// match ErrInvalidJSON.
+func (d *Decoder) Decode(data []byte, v interface{}) (warnings []*FieldError, err error) {
+	if d.opts.MaxBytes > 0 && len(data) > d.opts.MaxBytes {
+		return nil, fmt.Errorf("%w: %d bytes, limit is %d", ErrPayloadTooLarge, len(data), d.opts.MaxBytes)
+	}
+
+	w := &walker{data: data, dec: json.NewDecoder(bytes.NewReader(data)), opts: d.opts}
+	w.dec.UseNumber()
+	if err := w.walk(reflect.TypeOf(v)); err != nil {
+		return nil, err
+	}
+	if len(w.errs) > 0 {
+		return w.warnings, joinViolations(w.errs)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		// The walk catches what json.Unmarshal rejects, except objects
+		// and arrays rejected by custom unmarshalers.
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return w.warnings, NewFieldError(typeErr.Field,
+				&DecodeError{Offset: typeErr.Offset, Err: fmt.Errorf("%w: %v", ErrTypeMismatch, err)})
+		}
+		return w.warnings, fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+	}
+	return w.warnings, nil
+}
+
+type walker struct {
+	data     []byte
+	dec      *json.Decoder
+	opts     DecodeOptions
+	errs     []error
+	warnings []*FieldError
+}
+
+func (w *walker) walk(t reflect.Type) error {
+	if len(bytes.TrimSpace(w.data)) == 0 {
+		return &DecodeError{Offset: 0, Err: fmt.Errorf("%w: empty payload", ErrInvalidJSON)}
+	}
+	if err := w.value(t, ""); err != nil {
+		return err
+	}
+	offset := w.valueOffset()
+	if _, err := w.dec.Token(); err != io.EOF {
+		return &DecodeError{Offset: offset, Err: fmt.Errorf("%w: unexpected data after the top-level value", ErrInvalidJSON)}
+	}
+	return nil
+}
+
+// value walks the next JSON value, checking it against t. A nil t skips
+// the checks for the whole subtree.
+func (w *walker) value(t reflect.Type, path string) error {
+	offset := w.valueOffset()
+	token, err := w.dec.Token()
+	if err != nil {
+		return w.syntaxError(err)
+	}
+
+	t = derefType(t)
+	if t != nil && isCustomUnmarshaler(t) {
+		w.custom(path, offset, token, t)
+		t = nil
+	}
+	switch token {
+	case json.Delim('{'):
+		return w.object(t, path, offset)
+	case json.Delim('['):
+		return w.array(t, path, offset)
+	}
+	if t != nil && !scalarFits(token, t) {
+		w.mismatch(path, offset, token, t)
+	}
+	return nil
+}
+
+// custom runs the type's own unmarshaler on scalar values, so that errors
+// such as malformed timestamps are located too. Objects and arrays are
+// left to json.Unmarshal.
+func (w *walker) custom(path string, offset int64, token json.Token, t reflect.Type) {
+	if _, ok := token.(json.Delim); ok {
+		return
+	}
+	raw, err := json.Marshal(token)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(raw, reflect.New(t).Interface()); err != nil {
+		w.fieldError(path, &DecodeError{Offset: offset, Err: fmt.Errorf("%w: %v", ErrTypeMismatch, err)})
+	}
+}
+
+func (w *walker) object(t reflect.Type, path string, offset int64) error {
+	if t != nil && t.Kind() != reflect.Struct && t.Kind() != reflect.Map {
+		w.mismatch(path, offset, json.Delim('{'), t)
+		t = nil
+	}
+
+	for w.dec.More() {
+		keyOffset := w.valueOffset()
+		token, err := w.dec.Token()
+		if err != nil {
+			return w.syntaxError(err)
+		}
+		key, _ := token.(string)
+		fieldPath := joinPath(path, key)
+
+		var field jsonField
+		if t != nil {
+			var known bool
+			if field, known = lookupField(t, key); !known {
+				w.unknownField(fieldPath, keyOffset)
+			}
+		}
+		walk := w.value
+		if field.quoted {
+			walk = w.quotedValue
+		}
+		if err := walk(field.typ, fieldPath); err != nil {
+			return err
+		}
+	}
+	if _, err := w.dec.Token(); err != nil {
+		return w.syntaxError(err)
+	}
+	return nil
+}
+
+func (w *walker) array(t reflect.Type, path string, offset int64) error {
+	if t != nil && t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		w.mismatch(path, offset, json.Delim('['), t)
+		t = nil
+	}
+	var elem reflect.Type
+	if t != nil {
+		elem = t.Elem()
+	}
+
+	for n := 0; w.dec.More(); n++ {
+		if w.opts.MaxItems > 0 && n == w.opts.MaxItems {
+			w.errs = append(w.errs, NewFieldError(path, &DecodeError{Offset: offset,
+				Err: fmt.Errorf("%w: more than %d elements", ErrTooManyItems, w.opts.MaxItems)}))
+			elem = nil
+		}
+		if err := w.value(elem, fmt.Sprintf("%s[%d]", path, n)); err != nil {
+			return err
+		}
+	}
+	if _, err := w.dec.Token(); err != nil {
+		return w.syntaxError(err)
+	}
+	return nil
+}
+
+// quotedValue walks the value of a field tagged ",string": a scalar
+// encoded as JSON inside a JSON string, or null.
+func (w *walker) quotedValue(t reflect.Type, path string) error {
+	offset := w.valueOffset()
+	token, err := w.dec.Token()
+	if err != nil {
+		return w.syntaxError(err)
+	}
+
+	t = derefType(t)
+	switch token {
+	case json.Delim('{'):
+		w.quotedMismatch(path, offset, token, t)
+		return w.object(nil, path, offset)
+	case json.Delim('['):
+		w.quotedMismatch(path, offset, token, t)
+		return w.array(nil, path, offset)
+	case nil:
+		return nil
+	}
+	s, ok := token.(string)
+	if ok && isCustomUnmarshaler(t) {
+		// encoding/json hands the string contents to the unmarshaler.
+		if err := json.Unmarshal([]byte(s), reflect.New(t).Interface()); err != nil {
+			w.fieldError(path, &DecodeError{Offset: offset, Err: fmt.Errorf("%w: %v", ErrTypeMismatch, err)})
+		}
+		return nil
+	}
+	if ok {
+		if inner, ok := quotedToken(s); ok && scalarFits(inner, t) {
+			return nil
+		}
+	}
+	w.quotedMismatch(path, offset, token, t)
+	return nil
+}
+
+// quotedToken parses the contents of a ",string" value, which encoding/json
+// requires to be a single scalar without surrounding whitespace.
+func quotedToken(s string) (json.Token, bool) {
+	if s == "" || s != strings.TrimSpace(s) {
+		return nil, false
+	}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	token, err := dec.Token()
+	if err != nil {
+		return nil, false
+	}
+	if _, ok := token.(json.Delim); ok {
+		return nil, false
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, false
+	}
+	return token, true
+}
+
+func (w *walker) unknownField(path string, offset int64) {
+	err := NewFieldError(path, &DecodeError{Offset: offset, Err: ErrUnknownField})
+	if w.opts.UnknownFields == SeverityWarn {
+		w.warnings = append(w.warnings, err.(*FieldError))
+		return
+	}
+	w.errs = append(w.errs, err)
+}
+
+func (w *walker) mismatch(path string, offset int64, token json.Token, t reflect.Type) {
+	w.fieldError(path, &DecodeError{Offset: offset,
+		Err: fmt.Errorf("%w: %s cannot be decoded into %s", ErrTypeMismatch, describeToken(token), describeType(t))})
+}
+
+func (w *walker) quotedMismatch(path string, offset int64, token json.Token, t reflect.Type) {
+	w.fieldError(path, &DecodeError{Offset: offset,
+		Err: fmt.Errorf("%w: %s cannot be decoded into %s encoded as a string", ErrTypeMismatch, describeToken(token), describeType(t))})
+}
+
+// fieldError records err for path; errors of the top-level value have no
+// field.
+func (w *walker) fieldError(path string, err error) {
+	if path != "" {
+		err = NewFieldError(path, err)
+	}
+	w.errs = append(w.errs, err)
+}
+
+func (w *walker) syntaxError(err error) error {
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return &DecodeError{Offset: syntaxErr.Offset, Err: fmt.Errorf("%w: %v", ErrInvalidJSON, syntaxErr)}
+	case err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF):
+		return &DecodeError{Offset: int64(len(w.data)), Err: fmt.Errorf("%w: unexpected end of payload", ErrInvalidJSON)}
+	default:
+		return &DecodeError{Offset: w.dec.InputOffset(), Err: fmt.Errorf("%w: %v", ErrInvalidJSON, err)}
+	}
+}
+
+// valueOffset returns the offset of the next token, skipping the
+// whitespace and separators the decoder has not consumed yet.
+func (w *walker) valueOffset() int64 {
+	offset := w.dec.InputOffset()
+	for offset < int64(len(w.data)) {
+		switch w.data[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+var (
+	unmarshalerType     = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func derefType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() == reflect.Interface {
+		return nil
+	}
+	return t
+}
+
+func isCustomUnmarshaler(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(unmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// lookupField finds the field decoded from key, matching names the way
+// encoding/json does: exactly, or else case-insensitively, with the fields
+// of embedded structs promoted.
+func lookupField(t reflect.Type, key string) (jsonField, bool) {
+	if t.Kind() == reflect.Map {
+		return jsonField{typ: t.Elem()}, true
+	}
+
+	var folded *jsonField
+	fields := jsonFields(t)
+	for i, field := range fields {
+		if field.name == key {
+			return field, true
+		}
+		if folded == nil && strings.EqualFold(field.name, key) {
+			folded = &fields[i]
+		}
+	}
+	if folded == nil {
+		return jsonField{}, false
+	}
+	return *folded, true
+}
+
+type jsonField struct {
+	name   string
+	typ    reflect.Type
+	depth  int
+	tagged bool
+	// quoted is set by the ",string" tag option on scalar fields.
+	quoted bool
+}
+
+var jsonFieldCache sync.Map // reflect.Type -> []jsonField
+
+// jsonFields lists the JSON keys of a struct type, including the fields
+// promoted from embedded structs. Of fields sharing a name, the one
+// encoding/json would use is kept: the shallowest, then the tagged one;
+// names that stay ambiguous are dropped, as encoding/json ignores them.
+func jsonFields(t reflect.Type) []jsonField {
+	if cached, ok := jsonFieldCache.Load(t); ok {
+		return cached.([]jsonField)
+	}
+
+	var all []jsonField
+	collectFields(t, 0, map[reflect.Type]bool{}, &all)
+
+	byName := make(map[string][]jsonField)
+	var names []string
+	for _, field := range all {
+		if _, ok := byName[field.name]; !ok {
+			names = append(names, field.name)
+		}
+		byName[field.name] = append(byName[field.name], field)
+	}
+
+	fields := make([]jsonField, 0, len(names))
+	for _, name := range names {
+		if field, ok := dominantField(byName[name]); ok {
+			fields = append(fields, field)
+		}
+	}
+	jsonFieldCache.Store(t, fields)
+	return fields
+}
+
+func collectFields(t reflect.Type, depth int, visited map[reflect.Type]bool, out *[]jsonField) {
+	if visited[t] {
+		return
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				collectFields(embedded, depth+1, visited, out)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		tagged := name != ""
+		if !tagged {
+			name = field.Name
+		}
+		*out = append(*out, jsonField{
+			name:   name,
+			typ:    field.Type,
+			depth:  depth,
+			tagged: tagged,
+			quoted: hasTagOption(opts, "string") && isQuotable(field.Type),
+		})
+	}
+}
+
+func hasTagOption(opts, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// isQuotable reports whether encoding/json honours ",string" on a field of
+// type t: scalars, or unnamed pointers to them.
+func isQuotable(t reflect.Type) bool {
+	if t.Name() == "" && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func dominantField(fields []jsonField) (jsonField, bool) {
+	depth := fields[0].depth
+	for _, field := range fields[1:] {
+		if field.depth < depth {
+			depth = field.depth
+		}
+	}
+
+	var candidates, tagged []jsonField
+	for _, field := range fields {
+		if field.depth != depth {
+			continue
+		}
+		candidates = append(candidates, field)
+		if field.tagged {
+			tagged = append(tagged, field)
+		}
+	}
+	switch {
+	case len(tagged) == 1:
+		return tagged[0], true
+	case len(tagged) == 0 && len(candidates) == 1:
+		return candidates[0], true
+	}
+	return jsonField{}, false
+}
+
+func scalarFits(token json.Token, t reflect.Type) bool {
+	switch value := token.(type) {
+	case nil:
+		return true
+	case bool:
+		return t.Kind() == reflect.Bool
+	case string:
+		if isByteSlice(t) {
+			// encoding/json reads []byte from base64.
+			_, err := base64.StdEncoding.DecodeString(value)
+			return err == nil
+		}
+		return t.Kind() == reflect.String
+	case json.Number:
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := value.Int64()
+			return err == nil && !reflect.Zero(t).OverflowInt(n)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, err := strconv.ParseUint(value.String(), 10, 64)
+			return err == nil && !reflect.Zero(t).OverflowUint(n)
+		case reflect.Float32, reflect.Float64:
+			f, err := value.Float64()
+			return err == nil && !math.IsInf(f, 0)
+		}
+	}
+	return false
+}
+
+func describeToken(token json.Token) string {
+	switch value := token.(type) {
+	case json.Delim:
+		if value == '{' {
+			return "object"
+		}
+		return "array"
+	case bool:
+		return "boolean"
+	case string:
+		return fmt.Sprintf("string %q", value)
+	case json.Number:
+		return "number " + value.String()
+	}
+	return "value"
+}
+
+func isByteSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+func describeType(t reflect.Type) string {
+	if isByteSlice(t) {
+		return "base64-encoded bytes"
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		return "object"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "non-negative integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	}
+	return t.Kind().String()
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type decodeBase struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type decodeLeft struct {
+	Both string
+}
+
+type decodeRight struct {
+	Both string
+}
+
+type decodeTarget struct {
+	decodeBase
+	decodeLeft
+	decodeRight
+	Name   string         `json:"name"`
+	Count  uint64         `json:"count"`
+	Small  int8           `json:"small"`
+	Quoted int64          `json:"quoted,string"`
+	Flag   *bool          `json:"flag,omitempty,string"`
+	At     time.Time      `json:"at"`
+	Tags   []string       `json:"tags"`
+	Raw    []byte         `json:"raw"`
+	Meta   map[string]int `json:"meta"`
+}
+
+func TestDecoderDecode(t *testing.T) {
+	reject := DecodeOptions{UnknownFields: SeverityReject}
+	warn := DecodeOptions{UnknownFields: SeverityWarn}
+
+	tests := []struct {
+		name     string
+		opts     DecodeOptions
+		data     string
+		wantErr  error
+		field    string
+		offset   int64
+		warnings []string
+	}{
+		{
+			name: "all fields",
+			opts: reject,
+			data: `{"id":"a","name":"n","count":18446744073709551615,"small":-5,"quoted":"42","flag":"true",` +
+				`"at":"2024-01-01T10:00:00Z","tags":["x"],"raw":"aGk=","meta":{"k":1}}`,
+		},
+		{name: "case-folded keys", opts: reject, data: `{"NAME":"n","Id":"a","QUOTED":"1"}`},
+		{name: "null values", opts: reject, data: `{"name":null,"quoted":null,"flag":null,"at":null,"tags":null}`},
+		{name: "unknown field rejected", opts: reject, data: `{"name":"n","nmae":"x"}`, wantErr: ErrUnknownField, field: "nmae", offset: 12},
+		{name: "unknown field warned", opts: warn, data: `{"name":"n","nmae":"x"}`, warnings: []string{"nmae"}},
+		{name: "unknown nested field", opts: reject, data: `{"meta":{"a":1},"x":{"y":1}}`, wantErr: ErrUnknownField, field: "x", offset: 16},
+		{name: "ambiguous embedded field", opts: reject, data: `{"Both":"x"}`, wantErr: ErrUnknownField, field: "Both", offset: 1},
+		{name: "ambiguous embedded field warned", opts: warn, data: `{"Both":"x"}`, warnings: []string{"Both"}},
+		{name: "syntax error", opts: reject, data: `{"name":"n",}`, wantErr: ErrInvalidJSON, offset: 12},
+		{name: "unexpected end", opts: reject, data: `{"name":`, wantErr: ErrInvalidJSON, offset: 8},
+		{name: "empty payload", opts: reject, data: " ", wantErr: ErrInvalidJSON, offset: 0},
+		{name: "trailing data", opts: reject, data: `{"name":"n"} {}`, wantErr: ErrInvalidJSON, offset: 13},
+		{name: "string mismatch", opts: reject, data: `{"name":1}`, wantErr: ErrTypeMismatch, field: "name", offset: 8},
+		{name: "object mismatch", opts: reject, data: `{"tags":{"a":1}}`, wantErr: ErrTypeMismatch, field: "tags", offset: 8},
+		{name: "map value mismatch", opts: reject, data: `{"meta":{"a":"x"}}`, wantErr: ErrTypeMismatch, field: "meta.a", offset: 13},
+		{name: "int overflow", opts: reject, data: `{"small":200}`, wantErr: ErrTypeMismatch, field: "small", offset: 9},
+		{name: "negative uint", opts: reject, data: `{"count":-1}`, wantErr: ErrTypeMismatch, field: "count", offset: 9},
+		{name: "uint overflow", opts: reject, data: `{"count":18446744073709551616}`, wantErr: ErrTypeMismatch, field: "count", offset: 9},
+		{name: "fractional uint", opts: reject, data: `{"count":1.5}`, wantErr: ErrTypeMismatch, field: "count", offset: 9},
+		{name: "malformed time", opts: reject, data: `{"at":"yesterday"}`, wantErr: ErrTypeMismatch, field: "at", offset: 6},
+		{name: "time from number", opts: reject, data: `{"name":"n", "at":1}`, wantErr: ErrTypeMismatch, field: "at", offset: 18},
+		{name: "invalid base64", opts: reject, data: `{"raw":"!!"}`, wantErr: ErrTypeMismatch, field: "raw", offset: 7},
+		{name: "unquoted string option", opts: reject, data: `{"quoted":42}`, wantErr: ErrTypeMismatch, field: "quoted", offset: 10},
+		{name: "malformed string option", opts: reject, data: `{"quoted":"4x"}`, wantErr: ErrTypeMismatch, field: "quoted", offset: 10},
+		{name: "padded string option", opts: reject, data: `{"quoted":" 4"}`, wantErr: ErrTypeMismatch, field: "quoted", offset: 10},
+		{name: "wrong string option kind", opts: reject, data: `{"flag":"1"}`, wantErr: ErrTypeMismatch, field: "flag", offset: 8},
+		{name: "payload within limit", opts: DecodeOptions{MaxBytes: 12}, data: `{"name":"n"}`},
+		{name: "payload too large", opts: DecodeOptions{MaxBytes: 11}, data: `{"name":"n"}`, wantErr: ErrPayloadTooLarge, offset: -1},
+		{name: "items within limit", opts: DecodeOptions{MaxItems: 2}, data: `{"tags":["a","b"]}`},
+		{name: "too many items", opts: DecodeOptions{MaxItems: 2}, data: `{"tags":["a","b","c"]}`, wantErr: ErrTooManyItems, field: "tags", offset: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got decodeTarget
+			warnings, err := NewDecoder(tt.opts).Decode([]byte(tt.data), &got)
+
+			var fields []string
+			for _, warning := range warnings {
+				fields = append(fields, warning.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.warnings) {
+				t.Errorf("warnings = %v, want %v", fields, tt.warnings)
+			}
+
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Decode() error = %v", err)
+				}
+				var want decodeTarget
+				if err := json.Unmarshal([]byte(tt.data), &want); err != nil {
+					t.Fatalf("json.Unmarshal() error = %v", err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("Decode() = %+v, json.Unmarshal() = %+v", got, want)
+				}
+				return
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Decode() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.field != "" {
+				found := FieldErrors(err)
+				if len(found) != 1 || found[0].Field != tt.field {
+					t.Errorf("Decode() error = %v, want a single error of field %q", err, tt.field)
+				}
+			}
+			if tt.offset >= 0 {
+				var decodeErr *DecodeError
+				if !errors.As(err, &decodeErr) {
+					t.Fatalf("Decode() error = %v, want a DecodeError", err)
+				}
+				if decodeErr.Offset != tt.offset {
+					t.Errorf("offset = %d, want %d (%v)", decodeErr.Offset, tt.offset, err)
+				}
+			}
+		})
+	}
+}
+
+func TestDecoderAcceptsWhatUnmarshalAccepts(t *testing.T) {
+	// Payloads at the edges of what encoding/json accepts must not be
+	// reported by the walk.
+	payloads := []string{
+		`{"count":18446744073709551615}`,
+		`{"quoted":"-9223372036854775808"}`,
+		`{"flag":"false"}`,
+		`{"raw":""}`,
+		`{"Name":"n","id":"a"}`,
+	}
+	for _, payload := range payloads {
+		var v decodeTarget
+		if _, err := NewDecoder(DecodeOptions{UnknownFields: SeverityReject}).Decode([]byte(payload), &v); err != nil {
+			t.Errorf("Decode(%s) error = %v", payload, err)
+		}
+	}
+}
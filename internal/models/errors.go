@@ -1,6 +1,9 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrInvalidOrderUID    = errors.New("invalid order UID")
@@ -8,4 +11,24 @@ var (
 	ErrEmptyItems         = errors.New("items list is empty")
 	ErrOrderNotFound      = errors.New("order not found")
 	ErrInvalidJSON        = errors.New("invalid JSON data")
+	ErrTransient          = errors.New("transient error")
+	ErrStaleVersion       = errors.New("stale order version")
+	ErrInvalidFilter      = errors.New("invalid order filter")
+	ErrInvalidCursor      = errors.New("invalid pagination cursor")
+	ErrInvalidStatus      = errors.New("invalid order status")
+	ErrInvalidTransition  = errors.New("invalid order status transition")
+	ErrMissingChangedBy   = errors.New("changed_by is required")
+
+	ErrNegativeAmount      = errors.New("amount must not be negative")
+	ErrTotalMismatch       = errors.New("totals do not add up")
+	ErrTrackNumberMismatch = errors.New("item track number differs from the order's")
+	ErrInvalidCurrency     = errors.New("invalid ISO 4217 currency")
+	ErrInvalidEmail        = errors.New("invalid email address")
+	ErrInvalidPhone        = errors.New("invalid phone number")
+	ErrMissingDateCreated  = errors.New("date_created is required")
+
+	ErrUnknownField    = fmt.Errorf("%w: unknown field", ErrInvalidJSON)
+	ErrTypeMismatch    = fmt.Errorf("%w: type mismatch", ErrInvalidJSON)
+	ErrTooManyItems    = errors.New("too many array elements")
+	ErrPayloadTooLarge = errors.New("payload is too large")
 )
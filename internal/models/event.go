@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventOrderCreated       = "order.created"
+	EventOrderUpdated       = "order.updated"
+	EventOrderDeleted       = "order.deleted"
+	EventOrderStatusChanged = "order.status_changed"
+)
+
+// OutboxEvent is an order change recorded in the same transaction as the
+// change itself, waiting to be published.
+type OutboxEvent struct {
+	ID        int64
+	OrderUID  string
+	EventType string
+	Payload   json.RawMessage
+	CreatedAt time.Time
+	Attempts  int
+
+	// RequestID and Traceparent identify the request or message that caused
+	// the change, so the published event continues its trace.
+	RequestID   string
+	Traceparent string
+}
+
+// OrderEvent is the message published to downstream consumers for an
+// outbox event. Order is set for created and updated orders, StatusChange
+// for status transitions.
+type OrderEvent struct {
+	EventID      int64           `json:"event_id"`
+	EventType    string          `json:"event_type"`
+	OrderUID     string          `json:"order_uid"`
+	OccurredAt   time.Time       `json:"occurred_at"`
+	Order        json.RawMessage `json:"order,omitempty"`
+	StatusChange json.RawMessage `json:"status_change,omitempty"`
+}
+
+func (e *OutboxEvent) OrderEvent() OrderEvent {
+	event := OrderEvent{
+		EventID:    e.ID,
+		EventType:  e.EventType,
+		OrderUID:   e.OrderUID,
+		OccurredAt: e.CreatedAt,
+	}
+	if e.EventType == EventOrderStatusChanged {
+		event.StatusChange = e.Payload
+	} else {
+		event.Order = e.Payload
+	}
+	return event
+}
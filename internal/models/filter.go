@@ -0,0 +1,88 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+const (
+	SortDateCreatedAsc  = "date_created"
+	SortDateCreatedDesc = "-date_created"
+
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+type OrderFilter struct {
+	TrackNumber     string
+	CustomerID      string
+	DeliveryService string
+	Transaction     string
+	ChrtID          *int
+	NmID            *int
+	DateFrom        time.Time
+	DateTo          time.Time
+	Phone           string
+	Email           string
+	Sort            string
+	Cursor          *OrderCursor
+	Limit           int
+}
+
+// OrderCursor marks the last order of a page. It carries the sort it was
+// issued for, so a cursor cannot be replayed against a different ordering.
+type OrderCursor struct {
+	DateCreated time.Time `json:"d"`
+	OrderUID    string    `json:"u"`
+	Sort        string    `json:"s"`
+}
+
+type OrderPage struct {
+	Orders     []*Order `json:"orders"`
+	Count      int      `json:"count"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+func (f *OrderFilter) Normalize() error {
+	if f.Sort == "" {
+		f.Sort = SortDateCreatedDesc
+	}
+	if f.Sort != SortDateCreatedAsc && f.Sort != SortDateCreatedDesc {
+		return NewFieldError("sort", ErrInvalidFilter)
+	}
+
+	if f.Limit <= 0 {
+		f.Limit = DefaultSearchLimit
+	}
+	if f.Limit > MaxSearchLimit {
+		f.Limit = MaxSearchLimit
+	}
+
+	if !f.DateFrom.IsZero() && !f.DateTo.IsZero() && f.DateTo.Before(f.DateFrom) {
+		return NewFieldError("date_to", ErrInvalidFilter)
+	}
+
+	if f.Cursor != nil && f.Cursor.Sort != f.Sort {
+		return NewFieldError("cursor", ErrInvalidCursor)
+	}
+	return nil
+}
+
+func (c OrderCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeOrderCursor(value string) (*OrderCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor OrderCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.OrderUID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
@@ -6,20 +6,22 @@ import (
 )
 
 type Order struct {
-	OrderUID          string    `json:"order_uid" db:"order_uid"`
-	TrackNumber       string    `json:"track_number" db:"track_number"`
-	Entry             string    `json:"entry" db:"entry"`
-	Delivery          Delivery  `json:"delivery"`
-	Payment           Payment   `json:"payment"`
-	Items             []Item    `json:"items"`
-	Locale            string    `json:"locale" db:"locale"`
-	InternalSignature string    `json:"internal_signature" db:"internal_signature"`
-	CustomerID        string    `json:"customer_id" db:"customer_id"`
-	DeliveryService   string    `json:"delivery_service" db:"delivery_service"`
-	ShardKey          string    `json:"shardkey" db:"shardkey"`
-	SmID              int       `json:"sm_id" db:"sm_id"`
-	DateCreated       time.Time `json:"date_created" db:"date_created"`
-	OofShard          string    `json:"oof_shard" db:"oof_shard"`
+	OrderUID          string      `json:"order_uid" db:"order_uid"`
+	TrackNumber       string      `json:"track_number" db:"track_number"`
+	Entry             string      `json:"entry" db:"entry"`
+	Delivery          Delivery    `json:"delivery"`
+	Payment           Payment     `json:"payment"`
+	Items             []Item      `json:"items"`
+	Locale            string      `json:"locale" db:"locale"`
+	InternalSignature string      `json:"internal_signature" db:"internal_signature"`
+	CustomerID        string      `json:"customer_id" db:"customer_id"`
+	DeliveryService   string      `json:"delivery_service" db:"delivery_service"`
+	ShardKey          string      `json:"shardkey" db:"shardkey"`
+	SmID              int         `json:"sm_id" db:"sm_id"`
+	DateCreated       time.Time   `json:"date_created" db:"date_created"`
+	OofShard          string      `json:"oof_shard" db:"oof_shard"`
+	Version           int64       `json:"version" db:"version"`
+	Status            OrderStatus `json:"status" db:"status"`
 }
 
 type Delivery struct {
@@ -59,17 +61,13 @@ type Item struct {
 	Status      int    `json:"status" db:"status"`
 }
 
+// defaultValidator applies the rules with their default severities.
+var defaultValidator = NewOrderValidator()
+
+// Validate reports every rejecting violation of the default rules as a
+// FieldError. Use an OrderValidator for configured severities and warnings.
 func (o *Order) Validate() error {
-	if o.OrderUID == "" {
-		return ErrInvalidOrderUID
-	}
-	if o.TrackNumber == "" {
-		return ErrInvalidTrackNumber
-	}
-	if len(o.Items) == 0 {
-		return ErrEmptyItems
-	}
-	return nil
+	return defaultValidator.Validate(o).Err()
 }
 
 func (o *Order) ToJSON() ([]byte, error) {
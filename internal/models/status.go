@@ -0,0 +1,88 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+type OrderStatus string
+
+const (
+	StatusCreated    OrderStatus = "created"
+	StatusPaid       OrderStatus = "paid"
+	StatusAssembling OrderStatus = "assembling"
+	StatusShipped    OrderStatus = "shipped"
+	StatusDelivered  OrderStatus = "delivered"
+	StatusCancelled  OrderStatus = "cancelled"
+	StatusReturned   OrderStatus = "returned"
+)
+
+// transitions lists the statuses reachable from each status. Cancelled
+// and returned orders are final.
+var transitions = map[OrderStatus][]OrderStatus{
+	StatusCreated:    {StatusPaid, StatusCancelled},
+	StatusPaid:       {StatusAssembling, StatusCancelled},
+	StatusAssembling: {StatusShipped, StatusCancelled},
+	StatusShipped:    {StatusDelivered, StatusReturned},
+	StatusDelivered:  {StatusReturned},
+	StatusCancelled:  nil,
+	StatusReturned:   nil,
+}
+
+func (s OrderStatus) Valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTransition reports ErrInvalidTransition when next cannot follow s.
+func (s OrderStatus) ValidateTransition(next OrderStatus) error {
+	if !next.Valid() {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidStatus, next)
+	}
+	if !s.CanTransitionTo(next) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, s, next)
+	}
+	return nil
+}
+
+// StatusChange is a request to move an order to another status and, once
+// applied, an entry of its status history.
+type StatusChange struct {
+	OrderUID  string      `json:"order_uid"`
+	From      OrderStatus `json:"from,omitempty"`
+	Status    OrderStatus `json:"status"`
+	ChangedBy string      `json:"changed_by"`
+	Reason    string      `json:"reason,omitempty"`
+	ChangedAt time.Time   `json:"changed_at"`
+}
+
+func (c *StatusChange) Validate() error {
+	var errs []error
+	if c.OrderUID == "" {
+		errs = append(errs, NewFieldError("order_uid", ErrInvalidOrderUID))
+	}
+	if !c.Status.Valid() {
+		errs = append(errs, NewFieldError("status", fmt.Errorf("%w: %q", ErrInvalidStatus, c.Status)))
+	}
+	if c.ChangedBy == "" {
+		errs = append(errs, NewFieldError("changed_by", ErrMissingChangedBy))
+	}
+	return joinViolations(errs)
+}
+
+// StatusHistory is the current status of an order together with all
+// recorded transitions, oldest first.
+type StatusHistory struct {
+	OrderUID string         `json:"order_uid"`
+	Status   OrderStatus    `json:"status"`
+	History  []StatusChange `json:"history"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "errors"
+
+// FieldError ties a validation error to the JSON path of the offending
+// field, e.g. "items[0].price".
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func NewFieldError(field string, err error) error {
+	return &FieldError{Field: field, Err: err}
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// FieldErrors collects every FieldError in the tree of err, including
+// errors combined with errors.Join.
+func FieldErrors(err error) []*FieldError {
+	var fields []*FieldError
+	var walk func(err error)
+	walk = func(err error) {
+		switch e := err.(type) {
+		case nil:
+		case *FieldError:
+			fields = append(fields, e)
+		case interface{ Unwrap() []error }:
+			for _, inner := range e.Unwrap() {
+				walk(inner)
+			}
+		case interface{ Unwrap() error }:
+			walk(e.Unwrap())
+		}
+	}
+	walk(err)
+	return fields
+}
+
+// joinViolations keeps errors.Is working for single violations while still
+// reporting every violation found.
+func joinViolations(errs []error) error {
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,285 @@
+package models
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+type Severity string
+
+const (
+	// SeverityReject makes a violation fail validation.
+	SeverityReject Severity = "reject"
+	// SeverityWarn reports a violation without rejecting the order.
+	SeverityWarn Severity = "warn"
+)
+
+// Rule names, used as keys of the severity configuration. RuleRequired is
+// always enforced and cannot be configured.
+const (
+	RuleRequired        = "required"
+	RuleNonNegative     = "non_negative"
+	RuleGoodsTotal      = "goods_total"
+	RulePaymentAmount   = "payment_amount"
+	RuleItemTrackNumber = "item_track_number"
+	RuleCurrency        = "currency"
+	RuleEmail           = "email"
+	RulePhone           = "phone"
+	RuleDateCreated     = "date_created"
+)
+
+type orderRule struct {
+	name     string
+	severity Severity
+	check    func(o *Order) []error
+}
+
+// orderRules run in this order; severity is the default used when the
+// configuration does not override it.
+var orderRules = []orderRule{
+	{RuleRequired, SeverityReject, checkRequired},
+	{RuleNonNegative, SeverityReject, checkNonNegative},
+	{RuleDateCreated, SeverityReject, checkDateCreated},
+	{RuleCurrency, SeverityReject, checkCurrency},
+	{RuleGoodsTotal, SeverityWarn, checkGoodsTotal},
+	{RulePaymentAmount, SeverityWarn, checkPaymentAmount},
+	{RuleItemTrackNumber, SeverityWarn, checkItemTrackNumber},
+	{RuleEmail, SeverityWarn, checkEmail},
+	{RulePhone, SeverityWarn, checkPhone},
+}
+
+// Violation is a rule broken by an order, with the severity it was
+// evaluated with.
+type Violation struct {
+	Rule     string
+	Severity Severity
+	*FieldError
+}
+
+type ValidationResult struct {
+	Violations []Violation
+}
+
+// Err joins the rejecting violations as FieldErrors, or returns nil when
+// the order is accepted.
+func (r ValidationResult) Err() error {
+	var errs []error
+	for _, violation := range r.Violations {
+		if violation.Severity == SeverityReject {
+			errs = append(errs, violation.FieldError)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return joinViolations(errs)
+}
+
+// Warnings returns the violations that do not reject the order.
+func (r ValidationResult) Warnings() []Violation {
+	var warnings []Violation
+	for _, violation := range r.Violations {
+		if violation.Severity == SeverityWarn {
+			warnings = append(warnings, violation)
+		}
+	}
+	return warnings
+}
+
+// OrderValidator applies the order rules with configurable severities. It
+// is safe for concurrent use, and severities can be changed at runtime.
+type OrderValidator struct {
+	severities atomic.Pointer[map[string]Severity]
+}
+
+// NewOrderValidator returns a validator using the default severities.
+func NewOrderValidator() *OrderValidator {
+	v := &OrderValidator{}
+	severities, _ := resolveSeverities(nil)
+	v.severities.Store(&severities)
+	return v
+}
+
+// SetSeverities replaces the severity overrides, keyed by rule name.
+// Rules that are not listed use their default severity.
+func (v *OrderValidator) SetSeverities(overrides map[string]string) error {
+	severities, err := resolveSeverities(overrides)
+	if err != nil {
+		return err
+	}
+	v.severities.Store(&severities)
+	return nil
+}
+
+func (v *OrderValidator) Validate(o *Order) ValidationResult {
+	severities := *v.severities.Load()
+
+	var result ValidationResult
+	for _, rule := range orderRules {
+		for _, err := range rule.check(o) {
+			fieldErr, ok := err.(*FieldError)
+			if !ok {
+				fieldErr = &FieldError{Err: err}
+			}
+			result.Violations = append(result.Violations, Violation{
+				Rule:       rule.name,
+				Severity:   severities[rule.name],
+				FieldError: fieldErr,
+			})
+		}
+	}
+	return result
+}
+
+// CheckSeverities reports unknown rules and severities in overrides.
+func CheckSeverities(overrides map[string]string) error {
+	_, err := resolveSeverities(overrides)
+	return err
+}
+
+// ValidationRules lists the names of the configurable rules.
+func ValidationRules() []string {
+	var names []string
+	for _, rule := range orderRules {
+		if rule.name != RuleRequired {
+			names = append(names, rule.name)
+		}
+	}
+	return names
+}
+
+func resolveSeverities(overrides map[string]string) (map[string]Severity, error) {
+	severities := make(map[string]Severity, len(orderRules))
+	for _, rule := range orderRules {
+		severities[rule.name] = rule.severity
+	}
+
+	var unknown []string
+	for name, value := range overrides {
+		if _, ok := severities[name]; !ok || name == RuleRequired {
+			unknown = append(unknown, name)
+			continue
+		}
+		switch severity := Severity(value); severity {
+		case SeverityReject, SeverityWarn:
+			severities[name] = severity
+		default:
+			return nil, fmt.Errorf("severity %q of rule %s must be %q or %q", value, name, SeverityReject, SeverityWarn)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown validation rules %s, known rules are %s",
+			strings.Join(unknown, ", "), strings.Join(ValidationRules(), ", "))
+	}
+	return severities, nil
+}
+
+func checkRequired(o *Order) []error {
+	var errs []error
+	if o.OrderUID == "" {
+		errs = append(errs, NewFieldError("order_uid", ErrInvalidOrderUID))
+	}
+	if o.TrackNumber == "" {
+		errs = append(errs, NewFieldError("track_number", ErrInvalidTrackNumber))
+	}
+	if len(o.Items) == 0 {
+		errs = append(errs, NewFieldError("items", ErrEmptyItems))
+	}
+	return errs
+}
+
+func checkNonNegative(o *Order) []error {
+	var errs []error
+	check := func(field string, value int) {
+		if value < 0 {
+			errs = append(errs, NewFieldError(field, fmt.Errorf("%w: %d", ErrNegativeAmount, value)))
+		}
+	}
+
+	check("payment.amount", o.Payment.Amount)
+	check("payment.delivery_cost", o.Payment.DeliveryCost)
+	check("payment.goods_total", o.Payment.GoodsTotal)
+	check("payment.custom_fee", o.Payment.CustomFee)
+	for i, item := range o.Items {
+		check(fmt.Sprintf("items[%d].price", i), item.Price)
+		check(fmt.Sprintf("items[%d].sale", i), item.Sale)
+		check(fmt.Sprintf("items[%d].total_price", i), item.TotalPrice)
+	}
+	return errs
+}
+
+func checkDateCreated(o *Order) []error {
+	if o.DateCreated.IsZero() {
+		return []error{NewFieldError("date_created", ErrMissingDateCreated)}
+	}
+	return nil
+}
+
+func checkCurrency(o *Order) []error {
+	if !isoCurrencies[o.Payment.Currency] {
+		return []error{NewFieldError("payment.currency", fmt.Errorf("%w: %q", ErrInvalidCurrency, o.Payment.Currency))}
+	}
+	return nil
+}
+
+func checkGoodsTotal(o *Order) []error {
+	sum := 0
+	for _, item := range o.Items {
+		sum += item.TotalPrice
+	}
+	if o.Payment.GoodsTotal != sum {
+		return []error{NewFieldError("payment.goods_total",
+			fmt.Errorf("%w: goods_total is %d, items total_price sum is %d", ErrTotalMismatch, o.Payment.GoodsTotal, sum))}
+	}
+	return nil
+}
+
+func checkPaymentAmount(o *Order) []error {
+	expected := o.Payment.GoodsTotal + o.Payment.DeliveryCost + o.Payment.CustomFee
+	if o.Payment.Amount != expected {
+		return []error{NewFieldError("payment.amount",
+			fmt.Errorf("%w: amount is %d, goods_total + delivery_cost + custom_fee is %d", ErrTotalMismatch, o.Payment.Amount, expected))}
+	}
+	return nil
+}
+
+func checkItemTrackNumber(o *Order) []error {
+	var errs []error
+	for i, item := range o.Items {
+		if item.TrackNumber != o.TrackNumber {
+			errs = append(errs, NewFieldError(fmt.Sprintf("items[%d].track_number", i),
+				fmt.Errorf("%w: %q, order has %q", ErrTrackNumberMismatch, item.TrackNumber, o.TrackNumber)))
+		}
+	}
+	return errs
+}
+
+func checkEmail(o *Order) []error {
+	email := o.Delivery.Email
+	if email == "" {
+		return nil
+	}
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		return []error{NewFieldError("delivery.email", fmt.Errorf("%w: %q", ErrInvalidEmail, email))}
+	}
+	return nil
+}
+
+// phonePattern accepts E.164 numbers with an optional leading plus.
+var phonePattern = regexp.MustCompile(`^\+?[1-9][0-9]{6,14}$`)
+
+func checkPhone(o *Order) []error {
+	phone := o.Delivery.Phone
+	if phone == "" {
+		return nil
+	}
+	if !phonePattern.MatchString(phone) {
+		return []error{NewFieldError("delivery.phone", fmt.Errorf("%w: %q", ErrInvalidPhone, phone))}
+	}
+	return nil
+}
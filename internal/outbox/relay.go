@@ -0,0 +1,133 @@
+package outbox
+
+import (
+	"context"
+	"order-service/internal/metrics"
+	"order-service/internal/models"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Store gives the relay transactional access to the outbox table.
+type Store interface {
+	ProcessOutbox(ctx context.Context, limit int,
+		publish func(event *models.OutboxEvent) error, retryDelay func(attempts int) time.Duration) (int, error)
+	PurgeOutbox(ctx context.Context, before time.Time) (int64, error)
+}
+
+type Publisher interface {
+	PublishEvent(event *models.OutboxEvent) error
+}
+
+type Options struct {
+	BatchSize      int
+	PollInterval   time.Duration
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Retention is how long published events are kept. Zero keeps them
+	// forever.
+	Retention time.Duration
+}
+
+// Relay publishes outbox events to Kafka. Events are marked as published
+// only after Kafka acknowledged them, so delivery is at least once; a
+// failed event is retried with exponential backoff and blocks the events
+// of the same order behind it.
+type Relay struct {
+	store     Store
+	publisher Publisher
+	opts      Options
+	log       *logrus.Logger
+}
+
+func NewRelay(store Store, publisher Publisher, opts Options, logger *logrus.Logger) *Relay {
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		opts:      opts,
+		log:       logger,
+	}
+}
+
+// Run polls the outbox until ctx is cancelled. A batch that published
+// anything is followed immediately by the next one: a batch claims only
+// the oldest pending event of every order, so a backlog of one order
+// drains an event per batch rather than per poll interval.
+func (r *Relay) Run(ctx context.Context) {
+	r.log.Info("Starting outbox relay...")
+
+	ticker := time.NewTicker(r.opts.PollInterval)
+	defer ticker.Stop()
+
+	lastPurge := time.Now()
+	for {
+		for r.relayBatch(ctx) > 0 {
+			if ctx.Err() != nil {
+				break
+			}
+		}
+
+		// Purging a few dozen times per retention period keeps the table
+		// close to the configured size without deleting on every poll.
+		if r.opts.Retention > 0 && time.Since(lastPurge) >= r.opts.Retention/24 {
+			r.purge(ctx)
+			lastPurge = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			r.log.Info("Outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Relay) relayBatch(ctx context.Context) int {
+	processed, err := r.store.ProcessOutbox(ctx, r.opts.BatchSize, r.publish, r.backoff)
+	if err != nil && ctx.Err() == nil {
+		r.log.Errorf("Failed to process outbox: %v", err)
+	}
+	return processed
+}
+
+func (r *Relay) publish(event *models.OutboxEvent) error {
+	if err := r.publisher.PublishEvent(event); err != nil {
+		metrics.OutboxEventsTotal.WithLabelValues(event.EventType, "failed").Inc()
+		r.log.WithFields(logrus.Fields{
+			"request_id": event.RequestID,
+			"order_uid":  event.OrderUID,
+		}).Warnf("Failed to publish outbox event %d for order %s (attempt %d), will retry in %s: %v",
+			event.ID, event.OrderUID, event.Attempts+1, r.backoff(event.Attempts+1), err)
+		return err
+	}
+
+	metrics.OutboxEventsTotal.WithLabelValues(event.EventType, "published").Inc()
+	metrics.OutboxDelay.Observe(time.Since(event.CreatedAt).Seconds())
+	return nil
+}
+
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.opts.InitialBackoff
+	for i := 1; i < attempts && delay < r.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if r.opts.MaxBackoff > 0 && delay > r.opts.MaxBackoff {
+		delay = r.opts.MaxBackoff
+	}
+	return delay
+}
+
+func (r *Relay) purge(ctx context.Context) {
+	purged, err := r.store.PurgeOutbox(ctx, time.Now().Add(-r.opts.Retention))
+	if err != nil {
+		if ctx.Err() == nil {
+			r.log.Errorf("Failed to purge published outbox events: %v", err)
+		}
+		return
+	}
+	if purged > 0 {
+		r.log.Infof("Purged %d published outbox events", purged)
+	}
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"order-service/internal/models"
+	"syscall"
+
+	"github.com/lib/pq"
+)
+
+var transientSQLStates = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"55P03": true, // lock_not_available
+	"57P01": true, // admin_shutdown
+	"57P02": true, // crash_shutdown
+	"57P03": true, // cannot_connect_now
+	"53300": true, // too_many_connections
+}
+
+func classifyError(err error) error {
+	if err == nil || !isTransientError(err) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", models.ErrTransient, err)
+}
+
+func isTransientError(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return transientSQLStates[string(pqErr.Code)] || pqErr.Code.Class() == "08"
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
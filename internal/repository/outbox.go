@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"order-service/internal/correlation"
+	"order-service/internal/models"
+	"time"
+)
+
+const maxOutboxErrorLength = 1000
+
+func insertOutboxEvent(ctx context.Context, tx execer, orderUID, eventType string, payload interface{}) error {
+	var data []byte
+	if payload != nil {
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			return fmt.Errorf("failed to marshal outbox payload: %w", err)
+		}
+	}
+
+	ids := correlation.FromContext(ctx)
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO outbox (aggregate_id, event_type, payload, request_id, traceparent) VALUES ($1, $2, $3, $4, $5)`,
+		orderUID, eventType, nullableJSON(data), nullableString(ids.RequestID), nullableString(ids.Trace.String()))
+	if err != nil {
+		return fmt.Errorf("failed to insert outbox event: %w", err)
+	}
+	return nil
+}
+
+// ProcessOutbox locks up to limit events that are due and calls publish for
+// each of them while the locks are held, recording the outcome in the same
+// transaction.
+//
+// Only the oldest pending event of every order is eligible, so events of
+// one order are published strictly in order even when several relays run
+// at once: rows locked by another relay are skipped, and the events behind
+// them wait for the next round. On the first failure the event is
+// rescheduled after retryDelay and the rest of the batch is left for later.
+func (r *PostgresRepository) ProcessOutbox(ctx context.Context, limit int,
+	publish func(event *models.OutboxEvent) error, retryDelay func(attempts int) time.Duration) (processed int, err error) {
+	defer observe("process_outbox", time.Now(), &err)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, classifyError(fmt.Errorf("failed to begin transaction: %w", err))
+	}
+	defer tx.Rollback()
+
+	events, err := claimOutboxEvents(ctx, tx, limit)
+	if err != nil {
+		return 0, classifyError(err)
+	}
+
+	for _, event := range events {
+		if publishErr := publish(event); publishErr != nil {
+			message := publishErr.Error()
+			if len(message) > maxOutboxErrorLength {
+				message = message[:maxOutboxErrorLength]
+			}
+			_, err = tx.ExecContext(ctx, `
+				UPDATE outbox
+				SET attempts = attempts + 1, last_error = $2, next_attempt_at = NOW() + $3 * INTERVAL '1 millisecond'
+				WHERE id = $1`,
+				event.ID, message, retryDelay(event.Attempts+1).Milliseconds())
+			if err != nil {
+				return processed, classifyError(fmt.Errorf("failed to reschedule outbox event: %w", err))
+			}
+			break
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE outbox SET published_at = NOW(), attempts = attempts + 1, last_error = NULL WHERE id = $1`, event.ID)
+		if err != nil {
+			return processed, classifyError(fmt.Errorf("failed to mark outbox event as published: %w", err))
+		}
+		processed++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return processed, classifyError(fmt.Errorf("failed to commit outbox batch: %w", err))
+	}
+	return processed, nil
+}
+
+func claimOutboxEvents(ctx context.Context, tx *sql.Tx, limit int) ([]*models.OutboxEvent, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT o.id, o.aggregate_id, o.event_type, o.payload, o.created_at, o.attempts,
+			COALESCE(o.request_id, ''), COALESCE(o.traceparent, '')
+		FROM outbox o
+		WHERE o.published_at IS NULL
+			AND o.next_attempt_at <= NOW()
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox p
+				WHERE p.aggregate_id = o.aggregate_id AND p.published_at IS NULL AND p.id < o.id
+			)
+		ORDER BY o.id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.OutboxEvent
+	for rows.Next() {
+		var event models.OutboxEvent
+		var payload []byte
+		if err := rows.Scan(&event.ID, &event.OrderUID, &event.EventType, &payload,
+			&event.CreatedAt, &event.Attempts, &event.RequestID, &event.Traceparent); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		event.Payload = payload
+		events = append(events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read outbox events: %w", err)
+	}
+	return events, nil
+}
+
+// PurgeOutbox deletes events published before the given time.
+func (r *PostgresRepository) PurgeOutbox(ctx context.Context, before time.Time) (purged int64, err error) {
+	defer observe("purge_outbox", time.Now(), &err)
+
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM outbox WHERE published_at IS NOT NULL AND published_at < $1`, before)
+	if err != nil {
+		return 0, classifyError(fmt.Errorf("failed to purge outbox: %w", err))
+	}
+	return result.RowsAffected()
+}
+
+func nullableJSON(data []byte) interface{} {
+	if data == nil {
+		return nil
+	}
+	return string(data)
+}
+
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -1,55 +1,217 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"order-service/internal/metrics"
 	"order-service/internal/models"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
+const loadBatchSize = 1000
+
 type PostgresRepository struct {
-	db  *sql.DB
-	log *logrus.Logger
+	db       *sql.DB
+	timeouts Timeouts
+	log      *logrus.Logger
+}
+
+// Timeouts bound individual repository operations. Zero disables the
+// timeout and leaves only the caller's context in effect.
+type Timeouts struct {
+	Save   time.Duration
+	Get    time.Duration
+	Load   time.Duration
+	Search time.Duration
 }
 
-func NewPostgresRepository(dsn string, logger *logrus.Logger) (*PostgresRepository, error) {
-	db, err := sql.Open("postgres", dsn)
+type Options struct {
+	DSN             string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	ConnectAttempts   int
+	ConnectBackoff    time.Duration
+	ConnectMaxBackoff time.Duration
+
+	Timeouts Timeouts
+}
+
+// NewPostgresRepository opens the connection pool and waits for the
+// database to accept connections, retrying with exponential backoff so the
+// service can start alongside a database that is still booting.
+func NewPostgresRepository(ctx context.Context, opts Options, logger *logrus.Logger) (*PostgresRepository, error) {
+	db, err := sql.Open("postgres", opts.DSN)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	db.SetMaxIdleConns(opts.MaxIdleConns)
+	db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+
+	if err := waitForDatabase(ctx, db, opts, logger); err != nil {
+		db.Close()
+		return nil, err
 	}
 
 	return &PostgresRepository{
-		db:  db,
-		log: logger,
+		db:       db,
+		timeouts: opts.Timeouts,
+		log:      logger,
 	}, nil
 }
 
-func (r *PostgresRepository) SaveOrder(order *models.Order) error {
-	tx, err := r.db.Begin()
+func waitForDatabase(ctx context.Context, db *sql.DB, opts Options, logger *logrus.Logger) error {
+	backoff := opts.ConnectBackoff
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= opts.ConnectAttempts {
+			return fmt.Errorf("failed to ping database after %d attempts: %w", attempt, err)
+		}
+
+		logger.Warnf("Database is not ready (attempt %d/%d), retrying in %s: %v",
+			attempt, opts.ConnectAttempts, backoff, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("failed to ping database: %w", ctx.Err())
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if opts.ConnectMaxBackoff > 0 && backoff > opts.ConnectMaxBackoff {
+			backoff = opts.ConnectMaxBackoff
+		}
+	}
+}
+
+// SaveOrder inserts or updates an order and sets order.Status to the
+// stored status.
+func (r *PostgresRepository) SaveOrder(ctx context.Context, order *models.Order) (err error) {
+	defer observe("save_order", time.Now(), &err)
+
+	ctx, cancel := withTimeout(ctx, r.timeouts.Save)
+	defer cancel()
+
+	if err := r.saveOrder(ctx, order); err != nil {
+		if err == models.ErrStaleVersion {
+			r.log.WithContext(ctx).Infof("Order %s version %d is stale, keeping stored data", order.OrderUID, order.Version)
+			return err
+		}
+		return classifyError(err)
+	}
+
+	r.log.WithContext(ctx).Infof("Order %s saved successfully", order.OrderUID)
+	return nil
+}
+
+// DeleteOrder removes an order together with its delivery, payment and
+// items, and records an order.deleted event.
+func (r *PostgresRepository) DeleteOrder(ctx context.Context, orderUID string) (err error) {
+	defer observe("delete_order", time.Now(), &err)
+
+	ctx, cancel := withTimeout(ctx, r.timeouts.Save)
+	defer cancel()
+
+	if err := r.deleteOrder(ctx, orderUID); err != nil {
+		if err == models.ErrOrderNotFound {
+			return err
+		}
+		return classifyError(err)
+	}
+
+	r.log.WithContext(ctx).Infof("Order %s deleted", orderUID)
+	return nil
+}
+
+func (r *PostgresRepository) deleteOrder(ctx context.Context, orderUID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	q := traced(tx)
+
+	result, err := q.ExecContext(ctx, `DELETE FROM orders WHERE order_uid = $1`, orderUID)
+	if err != nil {
+		return fmt.Errorf("failed to delete order: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete order: %w", err)
+	}
+	if deleted == 0 {
+		return models.ErrOrderNotFound
+	}
+
+	if err := insertOutboxEvent(ctx, q, orderUID, models.EventOrderDeleted, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) saveOrder(ctx context.Context, order *models.Order) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
+	q := traced(tx)
 
-	_, err = tx.Exec(`
+	// Newer versions win; for producers that do not send a version the
+	// date_created timestamp decides. Stale redeliveries update nothing.
+	// xmax is zero only for freshly inserted rows. The status is managed by
+	// ChangeOrderStatus and never overwritten by a payload.
+	var inserted bool
+	err = q.QueryRowContext(ctx, `
 		INSERT INTO orders (
 			order_uid, track_number, entry, locale, internal_signature,
-			customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-		ON CONFLICT (order_uid) DO NOTHING`,
+			customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard, version
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (order_uid) DO UPDATE SET
+			track_number = EXCLUDED.track_number,
+			entry = EXCLUDED.entry,
+			locale = EXCLUDED.locale,
+			internal_signature = EXCLUDED.internal_signature,
+			customer_id = EXCLUDED.customer_id,
+			delivery_service = EXCLUDED.delivery_service,
+			shardkey = EXCLUDED.shardkey,
+			sm_id = EXCLUDED.sm_id,
+			date_created = EXCLUDED.date_created,
+			oof_shard = EXCLUDED.oof_shard,
+			version = EXCLUDED.version
+		WHERE (orders.version, orders.date_created) <= (EXCLUDED.version, EXCLUDED.date_created)
+		RETURNING xmax = 0, status`,
 		order.OrderUID, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature,
-		order.CustomerID, order.DeliveryService, order.ShardKey, order.SmID, order.DateCreated, order.OofShard)
+		order.CustomerID, order.DeliveryService, order.ShardKey, order.SmID, order.DateCreated, order.OofShard,
+		order.Version).Scan(&inserted, &order.Status)
 	if err != nil {
-		return fmt.Errorf("failed to insert order: %w", err)
+		if err == sql.ErrNoRows {
+			return models.ErrStaleVersion
+		}
+		return fmt.Errorf("failed to upsert order: %w", err)
 	}
 
-	_, err = tx.Exec(`
+	_, err = q.ExecContext(ctx, `
 		INSERT INTO deliveries (
 			order_uid, name, phone, zip, city, address, region, email
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
@@ -64,10 +226,17 @@ func (r *PostgresRepository) SaveOrder(order *models.Order) error {
 		order.OrderUID, order.Delivery.Name, order.Delivery.Phone, order.Delivery.Zip,
 		order.Delivery.City, order.Delivery.Address, order.Delivery.Region, order.Delivery.Email)
 	if err != nil {
-		return fmt.Errorf("failed to insert delivery: %w", err)
+		return fmt.Errorf("failed to upsert delivery: %w", err)
+	}
+
+	_, err = q.ExecContext(ctx, `DELETE FROM payments WHERE order_uid = $1 AND transaction <> $2`,
+		order.OrderUID, order.Payment.Transaction)
+	if err != nil {
+		return fmt.Errorf("failed to delete old payments: %w", err)
 	}
 
-	_, err = tx.Exec(`
+	var paymentOrderUID string
+	err = q.QueryRowContext(ctx, `
 		INSERT INTO payments (
 			transaction, order_uid, request_id, currency, provider,
 			amount, payment_dt, bank, delivery_cost, goods_total, custom_fee
@@ -81,22 +250,27 @@ func (r *PostgresRepository) SaveOrder(order *models.Order) error {
 			bank = EXCLUDED.bank,
 			delivery_cost = EXCLUDED.delivery_cost,
 			goods_total = EXCLUDED.goods_total,
-			custom_fee = EXCLUDED.custom_fee`,
+			custom_fee = EXCLUDED.custom_fee
+		WHERE payments.order_uid = EXCLUDED.order_uid
+		RETURNING order_uid`,
 		order.Payment.Transaction, order.OrderUID, order.Payment.RequestID,
 		order.Payment.Currency, order.Payment.Provider, order.Payment.Amount,
 		order.Payment.PaymentDt, order.Payment.Bank, order.Payment.DeliveryCost,
-		order.Payment.GoodsTotal, order.Payment.CustomFee)
+		order.Payment.GoodsTotal, order.Payment.CustomFee).Scan(&paymentOrderUID)
 	if err != nil {
-		return fmt.Errorf("failed to insert payment: %w", err)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("payment transaction %s belongs to another order", order.Payment.Transaction)
+		}
+		return fmt.Errorf("failed to upsert payment: %w", err)
 	}
 
-	_, err = tx.Exec(`DELETE FROM items WHERE order_uid = $1`, order.OrderUID)
+	_, err = q.ExecContext(ctx, `DELETE FROM items WHERE order_uid = $1`, order.OrderUID)
 	if err != nil {
 		return fmt.Errorf("failed to delete old items: %w", err)
 	}
 
 	for _, item := range order.Items {
-		_, err = tx.Exec(`
+		_, err = q.ExecContext(ctx, `
 			INSERT INTO items (
 				order_uid, chrt_id, track_number, price, rid, name,
 				sale, size, total_price, nm_id, brand, status
@@ -108,26 +282,57 @@ func (r *PostgresRepository) SaveOrder(order *models.Order) error {
 		}
 	}
 
+	eventType := models.EventOrderUpdated
+	if inserted {
+		eventType = models.EventOrderCreated
+		err = insertStatusHistory(ctx, q, &models.StatusChange{
+			OrderUID:  order.OrderUID,
+			Status:    order.Status,
+			ChangedBy: systemActor,
+			Reason:    "order created",
+			ChangedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := insertOutboxEvent(ctx, q, order.OrderUID, eventType, order); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	r.log.Infof("Order %s saved successfully", order.OrderUID)
 	return nil
 }
 
-func (r *PostgresRepository) GetOrder(orderUID string) (*models.Order, error) {
+func (r *PostgresRepository) GetOrder(ctx context.Context, orderUID string) (_ *models.Order, err error) {
+	defer observe("get_order", time.Now(), &err)
+
+	ctx, cancel := withTimeout(ctx, r.timeouts.Get)
+	defer cancel()
+
+	order, err := r.getOrder(ctx, orderUID)
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	return order, nil
+}
+
+func (r *PostgresRepository) getOrder(ctx context.Context, orderUID string) (*models.Order, error) {
 	order := &models.Order{}
+	q := traced(r.db)
 
-	row := r.db.QueryRow(`
+	row := q.QueryRowContext(ctx, `
 		SELECT order_uid, track_number, entry, locale, internal_signature,
-			   customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard
+			   customer_id, delivery_service, shardkey, sm_id, date_created, oof_shard, version, status
 		FROM orders WHERE order_uid = $1`, orderUID)
 
 	err := row.Scan(
 		&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale,
 		&order.InternalSignature, &order.CustomerID, &order.DeliveryService,
-		&order.ShardKey, &order.SmID, &order.DateCreated, &order.OofShard)
+		&order.ShardKey, &order.SmID, &order.DateCreated, &order.OofShard, &order.Version, &order.Status)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, models.ErrOrderNotFound
@@ -135,7 +340,7 @@ func (r *PostgresRepository) GetOrder(orderUID string) (*models.Order, error) {
 		return nil, fmt.Errorf("failed to get order: %w", err)
 	}
 
-	row = r.db.QueryRow(`
+	row = q.QueryRowContext(ctx, `
 		SELECT name, phone, zip, city, address, region, email
 		FROM deliveries WHERE order_uid = $1`, orderUID)
 
@@ -147,7 +352,7 @@ func (r *PostgresRepository) GetOrder(orderUID string) (*models.Order, error) {
 		return nil, fmt.Errorf("failed to get delivery info: %w", err)
 	}
 
-	row = r.db.QueryRow(`
+	row = q.QueryRowContext(ctx, `
 		SELECT transaction, request_id, currency, provider, amount,
 			   payment_dt, bank, delivery_cost, goods_total, custom_fee
 		FROM payments WHERE order_uid = $1`, orderUID)
@@ -161,7 +366,7 @@ func (r *PostgresRepository) GetOrder(orderUID string) (*models.Order, error) {
 		return nil, fmt.Errorf("failed to get payment info: %w", err)
 	}
 
-	rows, err := r.db.Query(`
+	rows, err := q.QueryContext(ctx, `
 		SELECT chrt_id, track_number, price, rid, name, sale,
 			   size, total_price, nm_id, brand, status
 		FROM items WHERE order_uid = $1`, orderUID)
@@ -187,33 +392,191 @@ func (r *PostgresRepository) GetOrder(orderUID string) (*models.Order, error) {
 	return order, nil
 }
 
-func (r *PostgresRepository) GetAllOrders() ([]*models.Order, error) {
-	rows, err := r.db.Query(`SELECT order_uid FROM orders ORDER BY date_created DESC`)
+func (r *PostgresRepository) GetAllOrders(ctx context.Context) ([]*models.Order, error) {
+	var orders []*models.Order
+	err := r.ForEachOrder(ctx, func(order *models.Order) error {
+		orders = append(orders, order)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get order UIDs: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
+	return orders, nil
+}
 
-	var orders []*models.Order
-	for rows.Next() {
-		var orderUID string
-		if err := rows.Scan(&orderUID); err != nil {
-			r.log.Errorf("Failed to scan order UID: %v", err)
-			continue
+// ForEachOrder streams every stored order to fn, newest first. Orders are
+// loaded in keyset-paginated batches with two queries per batch, so only one
+// batch is held in memory at a time. Returning an error from fn stops the
+// iteration and that error is returned as is. The load timeout applies to
+// each batch rather than to the whole iteration.
+func (r *PostgresRepository) ForEachOrder(ctx context.Context, fn func(order *models.Order) error) error {
+	var last *models.Order
+	for {
+		var (
+			orders  []*models.Order
+			err     error
+			started = time.Now()
+		)
+		batchCtx, cancel := withTimeout(ctx, r.timeouts.Load)
+		if last == nil {
+			orders, err = r.queryOrders(batchCtx, `
+				ORDER BY o.date_created DESC, o.order_uid DESC
+				LIMIT $1`, loadBatchSize)
+		} else {
+			orders, err = r.queryOrders(batchCtx, `
+				WHERE (o.date_created, o.order_uid) < ($1, $2)
+				ORDER BY o.date_created DESC, o.order_uid DESC
+				LIMIT $3`, last.DateCreated, last.OrderUID, loadBatchSize)
+		}
+		cancel()
+		observe("load_orders_batch", started, &err)
+		if err != nil {
+			return classifyError(err)
+		}
+
+		for _, order := range orders {
+			if err := fn(order); err != nil {
+				return err
+			}
+		}
+
+		if len(orders) < loadBatchSize {
+			return nil
 		}
+		last = orders[len(orders)-1]
+	}
+}
+
+const orderSelect = `
+	SELECT o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature,
+		   o.customer_id, o.delivery_service, o.shardkey, o.sm_id, o.date_created,
+		   o.oof_shard, o.version, o.status,
+		   d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
+		   p.transaction, p.request_id, p.currency, p.provider, p.amount,
+		   p.payment_dt, p.bank, p.delivery_cost, p.goods_total, p.custom_fee
+	FROM orders o
+	JOIN deliveries d ON d.order_uid = o.order_uid
+	JOIN LATERAL (
+		SELECT * FROM payments WHERE payments.order_uid = o.order_uid LIMIT 1
+	) p ON true`
+
+// queryOrders runs orderSelect followed by the given clauses and attaches
+// the items of all returned orders with a single additional query.
+func (r *PostgresRepository) queryOrders(ctx context.Context, clauses string, args ...interface{}) ([]*models.Order, error) {
+	rows, err := r.db.QueryContext(ctx, orderSelect+clauses, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orders: %w", err)
+	}
+	defer rows.Close()
 
-		order, err := r.GetOrder(orderUID)
+	var (
+		orders []*models.Order
+		uids   []string
+	)
+	byUID := make(map[string]*models.Order)
+	for rows.Next() {
+		order := &models.Order{}
+		var requestID sql.NullString
+		err := rows.Scan(
+			&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale,
+			&order.InternalSignature, &order.CustomerID, &order.DeliveryService,
+			&order.ShardKey, &order.SmID, &order.DateCreated, &order.OofShard, &order.Version, &order.Status,
+			&order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip,
+			&order.Delivery.City, &order.Delivery.Address, &order.Delivery.Region,
+			&order.Delivery.Email,
+			&order.Payment.Transaction, &requestID, &order.Payment.Currency,
+			&order.Payment.Provider, &order.Payment.Amount, &order.Payment.PaymentDt,
+			&order.Payment.Bank, &order.Payment.DeliveryCost, &order.Payment.GoodsTotal,
+			&order.Payment.CustomFee)
 		if err != nil {
-			r.log.Errorf("Failed to get order %s: %v", orderUID, err)
-			continue
+			return nil, fmt.Errorf("failed to scan order: %w", err)
 		}
+		order.Payment.RequestID = requestID.String
 
 		orders = append(orders, order)
+		uids = append(uids, order.OrderUID)
+		byUID[order.OrderUID] = order
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate orders: %w", err)
+	}
+
+	if len(orders) == 0 {
+		return orders, nil
+	}
+
+	itemRows, err := r.db.QueryContext(ctx, `
+		SELECT order_uid, chrt_id, track_number, price, rid, name, sale,
+			   size, total_price, nm_id, brand, status
+		FROM items WHERE order_uid = ANY($1)
+		ORDER BY order_uid, id`, pq.Array(uids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get items: %w", err)
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var (
+			orderUID string
+			item     models.Item
+		)
+		err := itemRows.Scan(
+			&orderUID, &item.ChrtID, &item.TrackNumber, &item.Price, &item.Rid,
+			&item.Name, &item.Sale, &item.Size, &item.TotalPrice,
+			&item.NmID, &item.Brand, &item.Status)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan item: %w", err)
+		}
+		if order, ok := byUID[orderUID]; ok {
+			order.Items = append(order.Items, item)
+		}
+	}
+	if err := itemRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate items: %w", err)
 	}
 
 	return orders, nil
 }
 
+func (r *PostgresRepository) HealthCheck(ctx context.Context) (map[string]interface{}, error) {
+	stats := r.db.Stats()
+	details := map[string]interface{}{
+		"open_connections": stats.OpenConnections,
+		"in_use":           stats.InUse,
+		"idle":             stats.Idle,
+	}
+
+	if err := r.db.PingContext(ctx); err != nil {
+		return details, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return details, nil
+}
+
+func (r *PostgresRepository) DB() *sql.DB {
+	return r.db
+}
+
 func (r *PostgresRepository) Close() error {
 	return r.db.Close()
 }
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func observe(operation string, started time.Time, err *error) {
+	metrics.DBQueryDuration.WithLabelValues(operation).Observe(time.Since(started).Seconds())
+	if *err != nil && !isExpectedError(*err) {
+		metrics.DBQueryErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+// isExpectedError reports outcomes that are part of normal operation rather
+// than database failures.
+func isExpectedError(err error) bool {
+	return errors.Is(err, models.ErrOrderNotFound) || errors.Is(err, models.ErrStaleVersion) ||
+		errors.Is(err, models.ErrInvalidTransition) || errors.Is(err, models.ErrInvalidStatus)
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"order-service/internal/models"
+	"strings"
+	"time"
+)
+
+// SearchOrders returns one page of orders matching filter, ordered by
+// date_created with order_uid as a tie-breaker so that cursors are stable.
+func (r *PostgresRepository) SearchOrders(ctx context.Context, filter models.OrderFilter) (_ *models.OrderPage, err error) {
+	defer observe("search_orders", time.Now(), &err)
+
+	ctx, cancel := withTimeout(ctx, r.timeouts.Search)
+	defer cancel()
+
+	if err := filter.Normalize(); err != nil {
+		return nil, err
+	}
+
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.TrackNumber != "" {
+		add("o.track_number = $%d", filter.TrackNumber)
+	}
+	if filter.CustomerID != "" {
+		add("o.customer_id = $%d", filter.CustomerID)
+	}
+	if filter.DeliveryService != "" {
+		add("o.delivery_service = $%d", filter.DeliveryService)
+	}
+	if filter.Phone != "" {
+		add("d.phone = $%d", filter.Phone)
+	}
+	if filter.Email != "" {
+		add("d.email = $%d", filter.Email)
+	}
+	if filter.Transaction != "" {
+		add("EXISTS (SELECT 1 FROM payments px WHERE px.order_uid = o.order_uid AND px.transaction = $%d)", filter.Transaction)
+	}
+	if filter.ChrtID != nil {
+		add("EXISTS (SELECT 1 FROM items ix WHERE ix.order_uid = o.order_uid AND ix.chrt_id = $%d)", *filter.ChrtID)
+	}
+	if filter.NmID != nil {
+		add("EXISTS (SELECT 1 FROM items ix WHERE ix.order_uid = o.order_uid AND ix.nm_id = $%d)", *filter.NmID)
+	}
+	if !filter.DateFrom.IsZero() {
+		add("o.date_created >= $%d", filter.DateFrom)
+	}
+	if !filter.DateTo.IsZero() {
+		add("o.date_created < $%d", filter.DateTo)
+	}
+
+	direction, comparison := "DESC", "<"
+	if filter.Sort == models.SortDateCreatedAsc {
+		direction, comparison = "ASC", ">"
+	}
+	if filter.Cursor != nil {
+		args = append(args, filter.Cursor.DateCreated, filter.Cursor.OrderUID)
+		conditions = append(conditions, fmt.Sprintf("(o.date_created, o.order_uid) %s ($%d, $%d)",
+			comparison, len(args)-1, len(args)))
+	}
+
+	var clauses strings.Builder
+	if len(conditions) > 0 {
+		clauses.WriteString("\n\tWHERE ")
+		clauses.WriteString(strings.Join(conditions, "\n\t  AND "))
+	}
+	args = append(args, filter.Limit+1)
+	fmt.Fprintf(&clauses, "\n\tORDER BY o.date_created %s, o.order_uid %s\n\tLIMIT $%d", direction, direction, len(args))
+
+	orders, err := r.queryOrders(ctx, clauses.String(), args...)
+	if err != nil {
+		return nil, classifyError(err)
+	}
+
+	page := &models.OrderPage{Orders: orders}
+	if len(orders) > filter.Limit {
+		page.Orders = orders[:filter.Limit]
+		last := page.Orders[len(page.Orders)-1]
+		page.NextCursor = models.OrderCursor{
+			DateCreated: last.DateCreated,
+			OrderUID:    last.OrderUID,
+			Sort:        filter.Sort,
+		}.Encode()
+	}
+	if page.Orders == nil {
+		page.Orders = []*models.Order{}
+	}
+	page.Count = len(page.Orders)
+
+	return page, nil
+}
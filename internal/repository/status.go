@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"order-service/internal/models"
+	"time"
+)
+
+// systemActor is recorded as the author of transitions made by the service
+// itself.
+const systemActor = "order-service"
+
+// ChangeOrderStatus moves an order to change.Status if the transition table
+// allows it, recording the transition in the status history and the outbox
+// in the same transaction. Repeating the current status is a no-op, so
+// redelivered events are harmless.
+func (r *PostgresRepository) ChangeOrderStatus(ctx context.Context, change *models.StatusChange) (err error) {
+	defer observe("change_order_status", time.Now(), &err)
+
+	ctx, cancel := withTimeout(ctx, r.timeouts.Save)
+	defer cancel()
+
+	if err := r.changeOrderStatus(ctx, change); err != nil {
+		if err == models.ErrOrderNotFound || errors.Is(err, models.ErrInvalidTransition) ||
+			errors.Is(err, models.ErrInvalidStatus) {
+			return err
+		}
+		return classifyError(err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) changeOrderStatus(ctx context.Context, change *models.StatusChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current models.OrderStatus
+	err = tx.QueryRowContext(ctx, `SELECT status FROM orders WHERE order_uid = $1 FOR UPDATE`,
+		change.OrderUID).Scan(&current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.ErrOrderNotFound
+		}
+		return fmt.Errorf("failed to lock order: %w", err)
+	}
+
+	if current == change.Status {
+		r.log.WithContext(ctx).Infof("Order %s is already %s", change.OrderUID, current)
+		return nil
+	}
+	if err := current.ValidateTransition(change.Status); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE orders SET status = $2 WHERE order_uid = $1`,
+		change.OrderUID, change.Status)
+	if err != nil {
+		return fmt.Errorf("failed to update order status: %w", err)
+	}
+
+	applied := *change
+	applied.From = current
+	if applied.ChangedAt.IsZero() {
+		applied.ChangedAt = time.Now().UTC()
+	}
+	if err := insertStatusHistory(ctx, tx, &applied); err != nil {
+		return err
+	}
+	if err := insertOutboxEvent(ctx, tx, change.OrderUID, models.EventOrderStatusChanged, &applied); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.log.WithContext(ctx).Infof("Order %s moved from %s to %s by %s", change.OrderUID, current, change.Status, change.ChangedBy)
+	return nil
+}
+
+func insertStatusHistory(ctx context.Context, tx execer, change *models.StatusChange) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO order_status_history (order_uid, from_status, to_status, changed_by, reason, changed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		change.OrderUID, sql.NullString{String: string(change.From), Valid: change.From != ""},
+		change.Status, change.ChangedBy, sql.NullString{String: change.Reason, Valid: change.Reason != ""},
+		change.ChangedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert status history: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetStatusHistory(ctx context.Context, orderUID string) (_ *models.StatusHistory, err error) {
+	defer observe("get_status_history", time.Now(), &err)
+
+	ctx, cancel := withTimeout(ctx, r.timeouts.Get)
+	defer cancel()
+
+	history, err := r.getStatusHistory(ctx, orderUID)
+	if err != nil {
+		if err == models.ErrOrderNotFound {
+			return nil, err
+		}
+		return nil, classifyError(err)
+	}
+	return history, nil
+}
+
+func (r *PostgresRepository) getStatusHistory(ctx context.Context, orderUID string) (*models.StatusHistory, error) {
+	history := &models.StatusHistory{OrderUID: orderUID, History: []models.StatusChange{}}
+
+	err := r.db.QueryRowContext(ctx, `SELECT status FROM orders WHERE order_uid = $1`, orderUID).
+		Scan(&history.Status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, models.ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get order status: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT from_status, to_status, changed_by, reason, changed_at
+		FROM order_status_history
+		WHERE order_uid = $1
+		ORDER BY id`, orderUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status history: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		change := models.StatusChange{OrderUID: orderUID}
+		var from, reason sql.NullString
+		if err := rows.Scan(&from, &change.Status, &change.ChangedBy, &reason, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan status history: %w", err)
+		}
+		change.From = models.OrderStatus(from.String)
+		change.Reason = reason.String
+		history.History = append(history.History, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read status history: %w", err)
+	}
+	return history, nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"order-service/internal/tracing"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+type queryer interface {
+	execer
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// tracedQueryer runs every statement of a *sql.DB or *sql.Tx in its own
+// span. Query spans cover the round trip, not the reading of the rows.
+type tracedQueryer struct {
+	q queryer
+}
+
+func traced(q queryer) tracedQueryer {
+	return tracedQueryer{q: q}
+}
+
+func (t tracedQueryer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startStatement(ctx, query)
+	result, err := t.q.ExecContext(ctx, query, args...)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (t tracedQueryer) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startStatement(ctx, query)
+	rows, err := t.q.QueryContext(ctx, query, args...)
+	tracing.End(span, err)
+	return rows, err
+}
+
+func (t tracedQueryer) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startStatement(ctx, query)
+	row := t.q.QueryRowContext(ctx, query, args...)
+	tracing.End(span, row.Err())
+	return row
+}
+
+func startStatement(ctx context.Context, query string) (context.Context, trace.Span) {
+	text := strings.Join(strings.Fields(query), " ")
+	operation, table := summarizeStatement(text)
+	name := operation
+	if table != "" {
+		name += " " + table
+	}
+
+	return tracing.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(operation),
+			semconv.DBCollectionName(table),
+			semconv.DBQueryText(text),
+		))
+}
+
+// summarizeStatement returns the SQL verb and the table it acts on, enough
+// to name a span like "INSERT orders".
+func summarizeStatement(query string) (operation, table string) {
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return "", ""
+	}
+	operation = strings.ToUpper(words[0])
+
+	marker := "FROM"
+	switch operation {
+	case "INSERT":
+		marker = "INTO"
+	case "UPDATE":
+		if len(words) > 1 {
+			return operation, words[1]
+		}
+		return operation, ""
+	}
+	for i, word := range words[:len(words)-1] {
+		if strings.EqualFold(word, marker) {
+			return operation, strings.Trim(words[i+1], "(")
+		}
+	}
+	return operation, ""
+}
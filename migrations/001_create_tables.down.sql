@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS update_orders_updated_at ON orders;
+DROP FUNCTION IF EXISTS update_updated_at_column();
+
+DROP TABLE IF EXISTS items;
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS deliveries;
+DROP TABLE IF EXISTS orders;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_items_nm_id;
+DROP INDEX IF EXISTS idx_deliveries_email;
+DROP INDEX IF EXISTS idx_deliveries_phone;
+DROP INDEX IF EXISTS idx_orders_date_created_order_uid;
+DROP INDEX IF EXISTS idx_orders_delivery_service;
@@ -0,0 +1,5 @@
+CREATE INDEX IF NOT EXISTS idx_orders_delivery_service ON orders(delivery_service);
+CREATE INDEX IF NOT EXISTS idx_orders_date_created_order_uid ON orders(date_created, order_uid);
+CREATE INDEX IF NOT EXISTS idx_deliveries_phone ON deliveries(phone);
+CREATE INDEX IF NOT EXISTS idx_deliveries_email ON deliveries(email);
+CREATE INDEX IF NOT EXISTS idx_items_nm_id ON items(nm_id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS order_status_history;
+DROP INDEX IF EXISTS idx_orders_status;
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE orders DROP COLUMN IF EXISTS status;
@@ -0,0 +1,2 @@
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS request_id VARCHAR(128);
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS traceparent VARCHAR(64);
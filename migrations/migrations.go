@@ -0,0 +1,9 @@
+package migrations
+
+import "embed"
+
+// FS holds the SQL migrations compiled into the service binary. Files are
+// named NNN_description.up.sql and NNN_description.down.sql.
+//
+//go:embed *.sql
+var FS embed.FS
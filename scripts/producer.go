@@ -6,6 +6,8 @@ import (
 	"log"
 	"time"
 
+	"order-service/internal/correlation"
+
 	"github.com/IBM/sarama"
 )
 
@@ -86,10 +88,15 @@ func main() {
 			continue
 		}
 
+		ids := correlation.Incoming("", "")
 		message := &sarama.ProducerMessage{
 			Topic: topic,
 			Key:   sarama.StringEncoder(order.OrderUID),
 			Value: sarama.ByteEncoder(orderJSON),
+			Headers: []sarama.RecordHeader{
+				{Key: []byte(correlation.KafkaHeaderRequestID), Value: []byte(ids.RequestID)},
+				{Key: []byte(correlation.HeaderTraceparent), Value: []byte(ids.Trace.String())},
+			},
 		}
 
 		partition, offset, err := producer.SendMessage(message)
@@ -98,8 +105,8 @@ func main() {
 			continue
 		}
 
-		fmt.Printf("Message %d sent successfully - Topic: %s, Partition: %d, Offset: %d, OrderUID: %s\n",
-			i+1, topic, partition, offset, order.OrderUID)
+		fmt.Printf("Message %d sent successfully - Topic: %s, Partition: %d, Offset: %d, OrderUID: %s, RequestID: %s, TraceID: %s\n",
+			i+1, topic, partition, offset, order.OrderUID, ids.RequestID, ids.Trace.TraceID)
 
 		time.Sleep(1 * time.Second)
 	}